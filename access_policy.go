@@ -0,0 +1,691 @@
+package tfe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OwnersTeam is the name of the team administering an organization
+const OwnersTeam = "owners"
+
+// ErrInvalidAccessPolicy is returned when an access policy is malformed
+var ErrInvalidAccessPolicy = errors.New("Invalid access policy")
+
+// ErrOwnersTeamProtected is returned when an access policy would delete or
+// empty the owners team, locking everyone out of the organization
+var ErrOwnersTeamProtected = errors.New("The owners team cannot be deleted or emptied")
+
+// AccessPolicy describes the desired teams of an organization, along with
+// their members and their access to workspaces and projects. It is usually
+// loaded from a JSON or YAML file with ParseAccessPolicy. The yaml tags
+// allow decoding documents using YAML features ParseAccessPolicy does not
+// support with another library, to be checked with Validate.
+type AccessPolicy struct {
+	// Teams are the teams managed by the policy. Other teams of the
+	// organization are left untouched, unless pruned.
+	Teams []TeamPolicy `json:"teams" yaml:"teams"`
+}
+
+// TeamPolicy is the desired configuration of a team. Nil Members,
+// Workspaces and Projects are left as they are, while empty ones remove all
+// members or access.
+type TeamPolicy struct {
+	Name       string `json:"name" yaml:"name"`
+	Visibility string `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+
+	// Members are the usernames of the team members
+	Members []string `json:"members" yaml:"members"`
+
+	// Workspaces and Projects map workspace and project names to the access
+	// of the team. Access to any other workspace or project is revoked.
+	Workspaces map[string]WorkspaceGrant `json:"workspaces" yaml:"workspaces"`
+	Projects   map[string]ProjectGrant   `json:"projects" yaml:"projects"`
+}
+
+// WorkspaceGrant is the access of a team to a workspace. Permissions only
+// apply to AccessCustom. Only the permissions they set are reconciled, the
+// others are left as they are.
+type WorkspaceGrant struct {
+	Access      string                `json:"access" yaml:"access"`
+	Permissions *WorkspacePermissions `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// ProjectGrant is the access of a team to a project. ProjectAccess and
+// WorkspaceAccess only apply to AccessCustom, like WorkspaceGrant.Permissions.
+type ProjectGrant struct {
+	Access          string                       `json:"access" yaml:"access"`
+	ProjectAccess   *ProjectPermissions          `json:"project-access,omitempty" yaml:"project-access,omitempty"`
+	WorkspaceAccess *ProjectWorkspacePermissions `json:"workspace-access,omitempty" yaml:"workspace-access,omitempty"`
+}
+
+// ParseAccessPolicy parses and validates an access policy. Documents
+// starting with { are parsed as JSON, others as YAML, limited to block
+// mappings and sequences, quoted or plain scalars and single-line flow
+// collections, without anchors or multi-line scalars. Unknown fields are
+// rejected to catch typos.
+func ParseAccessPolicy(b []byte) (AccessPolicy, error) {
+	if trimmed := bytes.TrimSpace(b); len(trimmed) == 0 || trimmed[0] != '{' {
+		doc, err := parseYAML(b)
+		if err != nil {
+			return AccessPolicy{}, fmt.Errorf("%w: %v", ErrInvalidAccessPolicy, err)
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return AccessPolicy{}, fmt.Errorf("%w: %v", ErrInvalidAccessPolicy, err)
+		}
+	}
+
+	var policy AccessPolicy
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&policy); err != nil {
+		return AccessPolicy{}, fmt.Errorf("%w: %v", ErrInvalidAccessPolicy, err)
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks that team names are unique, that access levels exist,
+// and that the owners team is neither emptied nor given explicit access,
+// owners having admin access to everything
+func (p AccessPolicy) Validate() error {
+	seen := map[string]bool{}
+	for _, t := range p.Teams {
+		if t.Name == "" {
+			return fmt.Errorf("%w: team without a name", ErrInvalidAccessPolicy)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("%w: team %q is defined twice", ErrInvalidAccessPolicy, t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.Name == OwnersTeam {
+			if t.Members != nil && len(t.Members) == 0 {
+				return ErrOwnersTeamProtected
+			}
+			if len(t.Workspaces) > 0 || len(t.Projects) > 0 {
+				return fmt.Errorf("%w: the owners team has admin access to all workspaces and projects", ErrInvalidAccessPolicy)
+			}
+		}
+
+		for ws, g := range t.Workspaces {
+			switch g.Access {
+			case AccessRead, AccessPlan, AccessWrite, AccessAdmin, AccessCustom:
+			default:
+				return fmt.Errorf("%w: team %q: workspace %q: unknown access %q", ErrInvalidAccessPolicy, t.Name, ws, g.Access)
+			}
+			if g.Permissions != nil && g.Access != AccessCustom {
+				return fmt.Errorf("%w: team %q: workspace %q: permissions require custom access", ErrInvalidAccessPolicy, t.Name, ws)
+			}
+		}
+		for project, g := range t.Projects {
+			switch g.Access {
+			case AccessRead, AccessWrite, AccessMaintain, AccessAdmin, AccessCustom:
+			default:
+				return fmt.Errorf("%w: team %q: project %q: unknown access %q", ErrInvalidAccessPolicy, t.Name, project, g.Access)
+			}
+			if (g.ProjectAccess != nil || g.WorkspaceAccess != nil) && g.Access != AccessCustom {
+				return fmt.Errorf("%w: team %q: project %q: permissions require custom access", ErrInvalidAccessPolicy, t.Name, project)
+			}
+		}
+	}
+	return nil
+}
+
+// AccessAction is the kind of change made by ReconcileAccess
+type AccessAction string
+
+// Access actions
+const (
+	AccessCreateTeam      AccessAction = "create-team"
+	AccessUpdateTeam      AccessAction = "update-team"
+	AccessDeleteTeam      AccessAction = "delete-team"
+	AccessAddMember       AccessAction = "add-member"
+	AccessRemoveMember    AccessAction = "remove-member"
+	AccessGrantWorkspace  AccessAction = "grant-workspace"
+	AccessUpdateWorkspace AccessAction = "update-workspace"
+	AccessRevokeWorkspace AccessAction = "revoke-workspace"
+	AccessGrantProject    AccessAction = "grant-project"
+	AccessUpdateProject   AccessAction = "update-project"
+	AccessRevokeProject   AccessAction = "revoke-project"
+)
+
+// AccessChange is a change made, or to be made, by ReconcileAccess. Target
+// is the username, workspace or project name the change applies to, and
+// Before and After the access levels, or visibility of the team.
+type AccessChange struct {
+	Action  AccessAction `json:"action"`
+	Team    string       `json:"team"`
+	Target  string       `json:"target,omitempty"`
+	Before  string       `json:"before,omitempty"`
+	After   string       `json:"after,omitempty"`
+	Applied bool         `json:"applied"`
+	Error   string       `json:"error,omitempty"`
+
+	apply func(c *Client, teamIDs map[string]string) error
+}
+
+// String formats the change as a diff line, e.g.
+// ~ team devs: workspace network (read -> write)
+func (ch AccessChange) String() string {
+	var sign, what string
+	switch ch.Action {
+	case AccessCreateTeam, AccessAddMember, AccessGrantWorkspace, AccessGrantProject:
+		sign = "+"
+	case AccessDeleteTeam, AccessRemoveMember, AccessRevokeWorkspace, AccessRevokeProject:
+		sign = "-"
+	default:
+		sign = "~"
+	}
+
+	switch ch.Action {
+	case AccessCreateTeam, AccessDeleteTeam:
+		return fmt.Sprintf("%s team %s", sign, ch.Team)
+	case AccessUpdateTeam:
+		return fmt.Sprintf("%s team %s: visibility %s -> %s", sign, ch.Team, ch.Before, ch.After)
+	case AccessAddMember, AccessRemoveMember:
+		what = "member " + ch.Target
+	case AccessGrantWorkspace, AccessUpdateWorkspace, AccessRevokeWorkspace:
+		what = "workspace " + ch.Target
+	default:
+		what = "project " + ch.Target
+	}
+
+	switch {
+	case ch.Before != "" && ch.After != "":
+		what += fmt.Sprintf(" (%s -> %s)", ch.Before, ch.After)
+	case ch.Before != "":
+		what += fmt.Sprintf(" (%s)", ch.Before)
+	case ch.After != "":
+		what += fmt.Sprintf(" (%s)", ch.After)
+	}
+	return fmt.Sprintf("%s team %s: %s", sign, ch.Team, what)
+}
+
+// AccessReport is the audit report of ReconcileAccess. Its JSON encoding is
+// suitable for audit logs.
+type AccessReport struct {
+	Organization string         `json:"organization"`
+	DryRun       bool           `json:"dry-run"`
+	StartedAt    time.Time      `json:"started-at"`
+	FinishedAt   time.Time      `json:"finished-at"`
+	Changes      []AccessChange `json:"changes"`
+}
+
+// String formats the changes as a diff, one line per change, failed
+// changes being followed by their error
+func (r AccessReport) String() string {
+	var b strings.Builder
+	for _, ch := range r.Changes {
+		b.WriteString(ch.String())
+		if ch.Error != "" {
+			fmt.Fprintf(&b, ": %s", ch.Error)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ReconcileAccessOptions controls ReconcileAccess
+type ReconcileAccessOptions struct {
+	// DryRun only reports the changes without making them
+	DryRun bool
+
+	// PruneTeams deletes the teams missing from the policy, except for the
+	// owners team which is never deleted
+	PruneTeams bool
+}
+
+// ReconcileAccess compares an access policy to the live teams of an
+// organization, and makes the changes needed for them to converge. Changes
+// are made in order and the first failure stops the reconciliation, the
+// report recording which changes were applied.
+// Requires 3+T+W+P requests, plus one request per change, where T is the
+// number of teams in the policy managing their members, W the number of
+// workspaces and P the number of projects, assuming a single page of each:
+// - ListTeams
+// - ListTeamMembers (T)
+// - ListWorkspaces
+// - ListTeamAccess (W)
+// - ListProjects
+// - ListTeamProjectAccess (P)
+func (c *Client) ReconcileAccess(ctx context.Context, organization string, policy AccessPolicy, options ReconcileAccessOptions) (report AccessReport, err error) {
+	report = AccessReport{
+		Organization: organization,
+		DryRun:       options.DryRun,
+		StartedAt:    time.Now(),
+		Changes:      []AccessChange{},
+	}
+	defer func() { report.FinishedAt = time.Now() }()
+
+	if err := policy.Validate(); err != nil {
+		return report, err
+	}
+
+	live, err := c.liveAccess(ctx, organization, policy)
+	if err != nil {
+		return report, err
+	}
+
+	changes, err := planAccess(organization, policy, live, options.PruneTeams)
+	if err != nil {
+		return report, err
+	}
+	report.Changes = changes
+	if options.DryRun {
+		return report, nil
+	}
+
+	teamIDs := map[string]string{}
+	for name, t := range live.teams {
+		teamIDs[name] = t.ID
+	}
+	for i := range report.Changes {
+		ch := &report.Changes[i]
+		err := ctx.Err()
+		if err == nil {
+			err = ch.apply(c, teamIDs)
+		}
+		if err != nil {
+			ch.Error = err.Error()
+			return report, fmt.Errorf("%s: %w", ch, err)
+		}
+		ch.Applied = true
+	}
+	return report, nil
+}
+
+// liveAccess is the current access configuration of an organization, teams
+// being referenced by name
+type liveAccess struct {
+	teams           map[string]Team
+	members         map[string][]string
+	workspaces      map[string]string
+	projects        map[string]string
+	workspaceAccess map[string]map[string]TeamAccess
+	projectAccess   map[string]map[string]TeamProjectAccess
+}
+
+func (c *Client) liveAccess(ctx context.Context, organization string, policy AccessPolicy) (*liveAccess, error) {
+	live := &liveAccess{
+		teams:           map[string]Team{},
+		members:         map[string][]string{},
+		workspaces:      map[string]string{},
+		projects:        map[string]string{},
+		workspaceAccess: map[string]map[string]TeamAccess{},
+		projectAccess:   map[string]map[string]TeamProjectAccess{},
+	}
+
+	teams, err := c.ListTeams(organization)
+	if err != nil {
+		return nil, err
+	}
+	teamNames := map[string]string{}
+	for _, t := range teams {
+		live.teams[t.Attributes.Name] = t
+		teamNames[t.ID] = t.Attributes.Name
+	}
+
+	for _, tp := range policy.Teams {
+		t, ok := live.teams[tp.Name]
+		if !ok || tp.Members == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		users, err := c.ListTeamMembers(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			live.members[tp.Name] = append(live.members[tp.Name], u.Attributes.Username)
+		}
+	}
+
+	workspaces, err := c.ListWorkspaces(organization)
+	if err != nil {
+		return nil, err
+	}
+	for _, ws := range workspaces {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		live.workspaces[ws.Attributes.Name] = ws.ID
+		access, err := c.ListTeamAccess(ws.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ta := range access {
+			team := teamNames[ta.TeamID()]
+			if live.workspaceAccess[team] == nil {
+				live.workspaceAccess[team] = map[string]TeamAccess{}
+			}
+			live.workspaceAccess[team][ws.Attributes.Name] = ta
+		}
+	}
+
+	projects, err := c.ListProjects(organization)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		live.projects[p.Attributes.Name] = p.ID
+		access, err := c.ListTeamProjectAccess(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, tpa := range access {
+			team := teamNames[tpa.TeamID()]
+			if live.projectAccess[team] == nil {
+				live.projectAccess[team] = map[string]TeamProjectAccess{}
+			}
+			live.projectAccess[team][p.Attributes.Name] = tpa
+		}
+	}
+
+	return live, nil
+}
+
+// planAccess lists the changes converging the live access to the policy,
+// team by team in name order, with team deletions last
+func planAccess(organization string, policy AccessPolicy, live *liveAccess, prune bool) ([]AccessChange, error) {
+	teams := make([]TeamPolicy, len(policy.Teams))
+	copy(teams, policy.Teams)
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+
+	changes := []AccessChange{}
+	inPolicy := map[string]bool{}
+	for _, tp := range teams {
+		tp := tp
+		name := tp.Name
+		inPolicy[name] = true
+
+		team, exists := live.teams[name]
+		switch {
+		case !exists:
+			changes = append(changes, AccessChange{
+				Action: AccessCreateTeam,
+				Team:   name,
+				After:  tp.Visibility,
+				apply: func(c *Client, ids map[string]string) error {
+					t, err := c.CreateTeam(organization, CreateTeamOptions{Name: name, Visibility: tp.Visibility})
+					if err != nil {
+						return err
+					}
+					ids[name] = t.ID
+					return nil
+				},
+			})
+		case tp.Visibility != "" && tp.Visibility != team.Attributes.Visibility:
+			changes = append(changes, AccessChange{
+				Action: AccessUpdateTeam,
+				Team:   name,
+				Before: team.Attributes.Visibility,
+				After:  tp.Visibility,
+				apply: func(c *Client, ids map[string]string) error {
+					_, err := c.UpdateTeam(ids[name], UpdateTeamOptions{Visibility: &tp.Visibility})
+					return err
+				},
+			})
+		}
+
+		if tp.Members != nil {
+			changes = append(changes, planMembers(name, tp.Members, live.members[name])...)
+		}
+
+		if tp.Workspaces != nil {
+			wsChanges, err := planWorkspaceAccess(name, tp.Workspaces, live)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, wsChanges...)
+		}
+
+		if tp.Projects != nil {
+			projectChanges, err := planProjectAccess(name, tp.Projects, live)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, projectChanges...)
+		}
+	}
+
+	if prune {
+		names := []string{}
+		for name := range live.teams {
+			if !inPolicy[name] && name != OwnersTeam {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			name := name
+			changes = append(changes, AccessChange{
+				Action: AccessDeleteTeam,
+				Team:   name,
+				apply: func(c *Client, ids map[string]string) error {
+					return c.DeleteTeam(ids[name])
+				},
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func planMembers(team string, desired, current []string) []AccessChange {
+	want := map[string]bool{}
+	for _, u := range desired {
+		want[u] = true
+	}
+	have := map[string]bool{}
+	for _, u := range current {
+		have[u] = true
+	}
+
+	changes := []AccessChange{}
+	for _, u := range unionKeys(want, have) {
+		u := u
+		switch {
+		case want[u] && !have[u]:
+			changes = append(changes, AccessChange{
+				Action: AccessAddMember,
+				Team:   team,
+				Target: u,
+				apply: func(c *Client, ids map[string]string) error {
+					return c.AddTeamMembers(ids[team], u)
+				},
+			})
+		case !want[u] && have[u]:
+			changes = append(changes, AccessChange{
+				Action: AccessRemoveMember,
+				Team:   team,
+				Target: u,
+				apply: func(c *Client, ids map[string]string) error {
+					return c.RemoveTeamMembers(ids[team], u)
+				},
+			})
+		}
+	}
+	return changes
+}
+
+func planWorkspaceAccess(team string, grants map[string]WorkspaceGrant, live *liveAccess) ([]AccessChange, error) {
+	current := live.workspaceAccess[team]
+
+	changes := []AccessChange{}
+	for _, ws := range unionKeys(grants, current) {
+		ws := ws
+		grant, wanted := grants[ws]
+		ta, has := current[ws]
+		options := TeamAccessOptions{Access: grant.Access}
+		if grant.Permissions != nil {
+			options.Permissions = *grant.Permissions
+		}
+
+		switch {
+		case wanted && !has:
+			workspaceID, ok := live.workspaces[ws]
+			if !ok {
+				return nil, fmt.Errorf("team %q: workspace %q: %w", team, ws, ErrWorkspaceNotFound)
+			}
+			changes = append(changes, AccessChange{
+				Action: AccessGrantWorkspace,
+				Team:   team,
+				Target: ws,
+				After:  grant.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					_, err := c.AddTeamAccess(workspaceID, ids[team], options)
+					return err
+				},
+			})
+		case wanted && !sameWorkspaceGrant(grant, ta.Attributes):
+			changes = append(changes, AccessChange{
+				Action: AccessUpdateWorkspace,
+				Team:   team,
+				Target: ws,
+				Before: ta.Attributes.Access,
+				After:  grant.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					_, err := c.UpdateTeamAccess(ta.ID, options)
+					return err
+				},
+			})
+		case !wanted:
+			changes = append(changes, AccessChange{
+				Action: AccessRevokeWorkspace,
+				Team:   team,
+				Target: ws,
+				Before: ta.Attributes.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					return c.RemoveTeamAccess(ta.ID)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func planProjectAccess(team string, grants map[string]ProjectGrant, live *liveAccess) ([]AccessChange, error) {
+	current := live.projectAccess[team]
+
+	changes := []AccessChange{}
+	for _, project := range unionKeys(grants, current) {
+		project := project
+		grant, wanted := grants[project]
+		tpa, has := current[project]
+		options := TeamProjectAccessOptions{
+			Access:          grant.Access,
+			ProjectAccess:   grant.ProjectAccess,
+			WorkspaceAccess: grant.WorkspaceAccess,
+		}
+
+		switch {
+		case wanted && !has:
+			projectID, ok := live.projects[project]
+			if !ok {
+				return nil, fmt.Errorf("team %q: project %q: %w", team, project, ErrProjectNotFound)
+			}
+			changes = append(changes, AccessChange{
+				Action: AccessGrantProject,
+				Team:   team,
+				Target: project,
+				After:  grant.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					_, err := c.AddTeamProjectAccess(projectID, ids[team], options)
+					return err
+				},
+			})
+		case wanted && !sameProjectGrant(grant, tpa.Attributes):
+			changes = append(changes, AccessChange{
+				Action: AccessUpdateProject,
+				Team:   team,
+				Target: project,
+				Before: tpa.Attributes.Access,
+				After:  grant.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					_, err := c.UpdateTeamProjectAccess(tpa.ID, options)
+					return err
+				},
+			})
+		case !wanted:
+			changes = append(changes, AccessChange{
+				Action: AccessRevokeProject,
+				Team:   team,
+				Target: project,
+				Before: tpa.Attributes.Access,
+				apply: func(c *Client, ids map[string]string) error {
+					return c.RemoveTeamProjectAccess(tpa.ID)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func sameWorkspaceGrant(grant WorkspaceGrant, live TeamAccessAttributes) bool {
+	if grant.Access != live.Access {
+		return false
+	}
+	if grant.Access != AccessCustom || grant.Permissions == nil {
+		return true
+	}
+	return sameWorkspacePermissions(*grant.Permissions, live.WorkspacePermissions)
+}
+
+func sameProjectGrant(grant ProjectGrant, live TeamProjectAccessAttributes) bool {
+	if grant.Access != live.Access {
+		return false
+	}
+	if grant.Access != AccessCustom {
+		return true
+	}
+	if grant.ProjectAccess != nil {
+		if live.ProjectAccess == nil {
+			return false
+		}
+		want, got := grant.ProjectAccess, live.ProjectAccess
+		if !samePermission(want.Settings, got.Settings) || !samePermission(want.Teams, got.Teams) {
+			return false
+		}
+	}
+	if grant.WorkspaceAccess != nil {
+		if live.WorkspaceAccess == nil {
+			return false
+		}
+		want, got := grant.WorkspaceAccess, live.WorkspaceAccess
+		return samePermission(want.Runs, got.Runs) &&
+			samePermission(want.Variables, got.Variables) &&
+			samePermission(want.StateVersions, got.StateVersions) &&
+			samePermission(want.SentinelMocks, got.SentinelMocks) &&
+			samePermission(want.Create, got.Create) &&
+			samePermission(want.Move, got.Move) &&
+			samePermission(want.Locking, got.Locking) &&
+			samePermission(want.Delete, got.Delete) &&
+			samePermission(want.RunTasks, got.RunTasks)
+	}
+	return true
+}
+
+func sameWorkspacePermissions(want, live WorkspacePermissions) bool {
+	return samePermission(want.Runs, live.Runs) &&
+		samePermission(want.Variables, live.Variables) &&
+		samePermission(want.StateVersions, live.StateVersions) &&
+		samePermission(want.SentinelMocks, live.SentinelMocks) &&
+		samePermission(want.WorkspaceLocking, live.WorkspaceLocking) &&
+		samePermission(want.RunTasks, live.RunTasks)
+}
+
+// samePermission reports whether a permission is unset, i.e. left as it is,
+// or set to its live value
+func samePermission[T comparable](want, live *T) bool {
+	return want == nil || live != nil && *want == *live
+}
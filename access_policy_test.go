@@ -0,0 +1,273 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAccessPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		err    error
+	}{
+		{`{"teams": [{"name": "devs", "members": ["alice"], "workspaces": {"network": {"access": "write"}}}]}`, nil},
+		{`{"teams": [{"name": "devs", "workspace": {}}]}`, ErrInvalidAccessPolicy},
+		{`{"teams": [{"name": "devs"}, {"name": "devs"}]}`, ErrInvalidAccessPolicy},
+		{`{"teams": [{"name": "devs", "workspaces": {"network": {"access": "maintain"}}}]}`, ErrInvalidAccessPolicy},
+		{`{"teams": [{"name": "devs", "workspaces": {"network": {"access": "read", "permissions": {"runs": "apply"}}}}]}`, ErrInvalidAccessPolicy},
+		{`{"teams": [{"name": "owners", "members": []}]}`, ErrOwnersTeamProtected},
+		{`{"teams": [{"name": "owners", "members": ["alice"]}]}`, nil},
+	}
+	for _, tt := range tests {
+		if _, err := ParseAccessPolicy([]byte(tt.policy)); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.policy, err, tt.err)
+		}
+	}
+}
+
+func TestParseAccessPolicyYAML(t *testing.T) {
+	yaml := `
+# Access to the network workspaces
+---
+teams:
+- name: devs
+  visibility: organization
+  members: [alice, "bob"]
+  workspaces:
+    network:
+      access: custom
+      permissions:
+        runs: apply
+        workspace-locking: false # managed by the platform team
+        run-tasks: true
+    app: {access: write}
+  projects:
+    core:
+      access: custom
+      project-access: {settings: read}
+      workspace-access:
+        create: true
+
+- name: 'auditors'
+  members:
+    - carol
+  workspaces: {}
+`
+	policy, err := ParseAccessPolicy([]byte(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ParseAccessPolicy([]byte(`{"teams": [
+		{
+			"name": "devs",
+			"visibility": "organization",
+			"members": ["alice", "bob"],
+			"workspaces": {
+				"network": {"access": "custom", "permissions": {"runs": "apply", "workspace-locking": false, "run-tasks": true}},
+				"app": {"access": "write"}
+			},
+			"projects": {
+				"core": {"access": "custom", "project-access": {"settings": "read"}, "workspace-access": {"create": true}}
+			}
+		},
+		{"name": "auditors", "members": ["carol"], "workspaces": {}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("got %+v\nwant %+v", policy, want)
+	}
+	if locking := policy.Teams[0].Workspaces["network"].Permissions.WorkspaceLocking; locking == nil || *locking {
+		t.Errorf("workspace-locking: false not kept")
+	}
+
+	for _, invalid := range []string{
+		"",
+		"teams:\n- name: devs\n  workspace: {}\n",
+		"teams:\n- name: devs\n  members: &members [alice]\n",
+		"teams:\n  - name: devs\n   members: []\n",
+	} {
+		if _, err := ParseAccessPolicy([]byte(invalid)); !errors.Is(err, ErrInvalidAccessPolicy) {
+			t.Errorf("%q: got %v, want ErrInvalidAccessPolicy", invalid, err)
+		}
+	}
+}
+
+func TestPlanAccess(t *testing.T) {
+	live := &liveAccess{
+		teams: map[string]Team{
+			"owners": {ID: "team-o", Attributes: TeamAttributes{Name: "owners"}},
+			"devs":   {ID: "team-d", Attributes: TeamAttributes{Name: "devs", Visibility: "secret"}},
+			"legacy": {ID: "team-l", Attributes: TeamAttributes{Name: "legacy"}},
+		},
+		members:    map[string][]string{"devs": {"alice", "bob"}},
+		workspaces: map[string]string{"network": "ws-1", "app": "ws-2", "old": "ws-3"},
+		projects:   map[string]string{"core": "prj-1"},
+		workspaceAccess: map[string]map[string]TeamAccess{
+			"devs": {
+				"network": {ID: "tws-1", Attributes: TeamAccessAttributes{Access: AccessRead}},
+				"app":     {ID: "tws-2", Attributes: TeamAccessAttributes{Access: AccessWrite}},
+				"old":     {ID: "tws-3", Attributes: TeamAccessAttributes{Access: AccessAdmin}},
+			},
+		},
+		projectAccess: map[string]map[string]TeamProjectAccess{},
+	}
+	policy := AccessPolicy{Teams: []TeamPolicy{
+		{
+			Name:       "devs",
+			Visibility: "organization",
+			Members:    []string{"alice", "carol"},
+			Workspaces: map[string]WorkspaceGrant{
+				"network": {Access: AccessWrite},
+				"app":     {Access: AccessWrite},
+			},
+			Projects: map[string]ProjectGrant{"core": {Access: AccessMaintain}},
+		},
+		{Name: "auditors", Members: []string{"dave"}},
+	}}
+
+	changes, err := planAccess("org", policy, live, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, ch := range changes {
+		got = append(got, ch.String())
+	}
+	want := []string{
+		"+ team auditors",
+		"+ team auditors: member dave",
+		"~ team devs: visibility secret -> organization",
+		"- team devs: member bob",
+		"+ team devs: member carol",
+		"~ team devs: workspace network (read -> write)",
+		"- team devs: workspace old (admin)",
+		"+ team devs: project core (maintain)",
+		"- team legacy",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	policy.Teams[0].Workspaces["missing"] = WorkspaceGrant{Access: AccessRead}
+	if _, err := planAccess("org", policy, live, false); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("got %v, want ErrWorkspaceNotFound", err)
+	}
+}
+
+func TestReconcileAccess(t *testing.T) {
+	requests := []string{}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/organizations/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `{"data": {"id": "team-new", "attributes": {"name": "devs"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "team-o", "attributes": {"name": "owners"}}]}`)
+	})
+	mux.HandleFunc("/api/v2/organizations/org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "ws-1", "attributes": {"name": "network"}}]}`)
+	})
+	mux.HandleFunc("/api/v2/organizations/org/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	mux.HandleFunc("/api/v2/team-workspaces", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `{"data": {"id": "tws-1"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	mux.HandleFunc("/api/v2/teams/team-new/relationships/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	policy := AccessPolicy{Teams: []TeamPolicy{{
+		Name:       "devs",
+		Members:    []string{"alice"},
+		Workspaces: map[string]WorkspaceGrant{"network": {Access: AccessPlan}},
+	}}}
+
+	c := New("token", server.URL)
+	report, err := c.ReconcileAccess(context.Background(), "org", policy, ReconcileAccessOptions{DryRun: true, PruneTeams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("dry run made changes: %v", requests)
+	}
+	if want := "+ team devs\n+ team devs: member alice\n+ team devs: workspace network (plan)\n"; report.String() != want {
+		t.Errorf("got diff\n%s", report)
+	}
+
+	report, err = c.ReconcileAccess(context.Background(), "org", policy, ReconcileAccessOptions{PruneTeams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /api/v2/organizations/org/teams",
+		"POST /api/v2/teams/team-new/relationships/users",
+		"POST /api/v2/team-workspaces",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v", requests)
+	}
+	for _, ch := range report.Changes {
+		if !ch.Applied {
+			t.Errorf("%s not applied", ch)
+		}
+	}
+	if report.FinishedAt.IsZero() || report.FinishedAt.Before(report.StartedAt) {
+		t.Errorf("got report from %v to %v", report.StartedAt, report.FinishedAt)
+	}
+
+	// Failed reconciliations are timed too
+	policy.Teams[0].Name = ""
+	if report, err = c.ReconcileAccess(context.Background(), "org", policy, ReconcileAccessOptions{}); err == nil || report.FinishedAt.IsZero() {
+		t.Errorf("got %v, finished at %v", err, report.FinishedAt)
+	}
+}
+
+func TestSameWorkspaceGrant(t *testing.T) {
+	apply, read, enabled, disabled := "apply", "read", true, false
+	live := TeamAccessAttributes{Access: AccessCustom, WorkspacePermissions: WorkspacePermissions{
+		Runs:             &apply,
+		Variables:        &read,
+		WorkspaceLocking: &enabled,
+		RunTasks:         &enabled,
+	}}
+
+	tests := []struct {
+		permissions WorkspacePermissions
+		same        bool
+	}{
+		{WorkspacePermissions{}, true},
+		{WorkspacePermissions{Runs: &apply}, true},
+		{WorkspacePermissions{Runs: &apply, WorkspaceLocking: &enabled}, true},
+		{WorkspacePermissions{Runs: &read}, false},
+		{WorkspacePermissions{WorkspaceLocking: &disabled}, false},
+		{WorkspacePermissions{StateVersions: &read}, false},
+	}
+	for _, tt := range tests {
+		grant := WorkspaceGrant{Access: AccessCustom, Permissions: &tt.permissions}
+		if got := sameWorkspaceGrant(grant, live); got != tt.same {
+			t.Errorf("%+v: got %v, want %v", tt.permissions, got, tt.same)
+		}
+	}
+}
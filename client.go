@@ -2,6 +2,7 @@ package tfe
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ var (
 	ErrWorkspaceNotFound    = errors.New("Workspace not found")
 	ErrStateVersionNotFound = errors.New("State version not found")
 	ErrBadStatus            = errors.New("Unrecognized status code")
+	ErrConflict             = errors.New("Request conflicts with the current state of the resource")
 )
 
 type PaginatedResponse struct {
@@ -41,18 +43,33 @@ type PaginationInfo struct {
 	CurrentPage int `json:"current-page"`
 	NextPage    int `json:"next-page"`
 	TotalPages  int `json:"total-pages"`
+	TotalCount  int `json:"total-count"`
 }
 
 // Client exposes an API for communicating with Terraform Enterprise
 type Client struct {
 	// AtlasToken is the token used to authenticate with Terraform Enterprise,
-	// you can generate one from the Terraform Enterprise UI
-	AtlasToken string
+	// you can generate one from the Terraform Enterprise UI. It is redacted
+	// when formatted, string variables must be converted with Secret(token).
+	AtlasToken Secret
 
 	// BaseURL is the base used for all api calls.  If you are using
 	// Terraform Enterprise SaaS, you can set this to DefaultBaseURL
 	BaseURL string
 
+	// MaxStateSize limits the size of downloaded state files, zero means no
+	// limit
+	MaxStateSize int64
+
+	// StateCache, when set, caches downloaded state files on disk, see
+	// StateCache
+	StateCache *StateCache
+
+	// Secrets resolves secret references such as env://NAME in the values
+	// of variables created or updated with ResolveSecrets set, right before
+	// they are sent, see SecretResolver
+	Secrets *SecretResolver
+
 	client *http.Client
 }
 
@@ -71,7 +88,7 @@ func New(atlasToken string, baseURL string) *Client {
 // but with a custom http.Client
 func NewWithClient(atlasToken string, baseURL string, client *http.Client) *Client {
 	return &Client{
-		AtlasToken: atlasToken,
+		AtlasToken: Secret(atlasToken),
 		BaseURL:    baseURL,
 		client:     client,
 	}
@@ -98,7 +115,7 @@ func (c *Client) ListOrganizations() ([]Organization, error) {
 	for resp.Meta.Pagination.CurrentPage < resp.Meta.Pagination.TotalPages {
 		q := url.Values{}
 		q.Add("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
-		if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err := c.do("GET", path, nil, q, &resp); err != nil {
 			return []Organization{}, err
 		}
 		orgs = append(orgs, resp.Data...)
@@ -211,8 +228,9 @@ func (c *Client) CreateWorkspace(organization string, options CreateWorkspaceOpt
 			Name:             options.Name,
 			TerraformVersion: options.TerraformVersion,
 			VCSRepo: VCSRepo{
-				Identifier:   options.VCSIdentifier,
-				OauthTokenID: options.VCSOauthKeyID,
+				Identifier:        options.VCSIdentifier,
+				OauthTokenID:      options.VCSOauthKeyID,
+				GHAInstallationID: options.VCSGHAInstallationID,
 			},
 		},
 	}
@@ -234,33 +252,11 @@ func (c *Client) CreateWorkspace(organization string, options CreateWorkspaceOpt
 	return resp.Data, nil
 }
 
-// Assigns SSH Key for a given workspace
-// PATCH - /api/v2/
+// AssignWorkspaceSSHKey assigns an SSH key to a workspace
+// Requires 1 request:
+// - PATCH /api/v2/workspaces/:workspaceID/relationships/ssh-key
 func (c *Client) AssignWorkspaceSSHKey(workspaceID string, sshKeyID string) error {
-	path := fmt.Sprintf("/api/v2/workspaces/%s/relationships/ssh-key", workspaceID)
-
-	payload := AssignSSHKeyPayload{
-		Type: "workspaces",
-		Data: SSHKeyAttributes{
-			ID: sshKeyID,
-		},
-	}
-
-	b, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	type wrapper struct {
-		Data Workspace `json:"data"`
-	}
-
-	var resp wrapper
-	if err := c.do(http.MethodPatch, path, bytes.NewBuffer(b), nil, &resp); err != nil {
-		return err
-	}
-
-	return nil
+	return c.setWorkspaceSSHKey(workspaceID, &sshKeyID)
 }
 
 // Creates a new Variable for a given workspace
@@ -268,6 +264,11 @@ func (c *Client) AssignWorkspaceSSHKey(workspaceID string, sshKeyID string) erro
 func (c *Client) CreateVariable(workspaceID string, options CreateVariableOptions) (Variable, error) {
 	path := "/api/v2/vars"
 
+	options, err := c.resolveVariable(options)
+	if err != nil {
+		return Variable{}, err
+	}
+
 	type wrapper struct {
 		Data Variable `json:"data"`
 	}
@@ -304,13 +305,48 @@ func (c *Client) CreateVariable(workspaceID string, options CreateVariableOption
 	return resp.Data, nil
 }
 
-// ListStateVersions lists all state versions for a given workspace
+// ListVariables lists the variables defined directly on a workspace
+// Requires 1 request:
+// - /api/v2/workspaces/:workspaceID/vars
+func (c *Client) ListVariables(workspaceID string) ([]Variable, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/vars", workspaceID)
+
+	type wrapper struct {
+		Data []Variable `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return []Variable{}, ErrWorkspaceNotFound
+		}
+		return []Variable{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// ListStateVersions lists all state versions for a given workspace, newest
+// first
 // Requires P requests, where P is the number of pages
 // - /api/v2/state-versions
 func (c *Client) ListStateVersions(organization, workspace string) ([]StateVersion, error) {
+	return c.ListStateVersionsWithOptions(organization, workspace, ListStateVersionsOptions{})
+}
+
+// ListStateVersionsWithOptions lists the state versions of a workspace
+// matching the options. The API returns state versions newest first, so
+// listing stops as soon as a state version older than Since is seen or
+// MaxCount state versions are found, without fetching the remaining pages.
+// Requires at most P requests, where P is the number of pages
+// - /api/v2/state-versions
+func (c *Client) ListStateVersionsWithOptions(organization, workspace string, options ListStateVersionsOptions) ([]StateVersion, error) {
 	q := url.Values{}
 	q.Add("filter[organization][name]", organization)
 	q.Add("filter[workspace][name]", workspace)
+	if options.PageSize > 0 {
+		q.Add("page[size]", strconv.Itoa(options.PageSize))
+	}
 	svs := []StateVersion{}
 
 	path := "/api/v2/state-versions"
@@ -320,29 +356,37 @@ func (c *Client) ListStateVersions(organization, workspace string) ([]StateVersi
 		Data []StateVersion `json:"data"`
 	}
 
-	var resp wrapper
-	if err := c.do("GET", path, nil, q, &resp); err != nil {
-		if err == ErrNotFound {
-			return []StateVersion{}, ErrStateVersionNotFound
-		}
-		return []StateVersion{}, err
-	}
-	svs = append(svs, resp.Data...)
-
-	for resp.Meta.Pagination.CurrentPage < resp.Meta.Pagination.TotalPages {
-		q = url.Values{}
-		q.Add("filter[organization][name]", organization)
-		q.Add("filter[workspace][name]", workspace)
-		q.Add("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
+	for {
+		var resp wrapper
 		if err := c.do("GET", path, nil, q, &resp); err != nil {
 			if err == ErrNotFound {
 				return []StateVersion{}, ErrStateVersionNotFound
 			}
 			return []StateVersion{}, err
 		}
-		svs = append(svs, resp.Data...)
+
+		for _, sv := range resp.Data {
+			created := sv.Attributes.CreatedAt
+			if !options.Since.IsZero() && created.Before(options.Since) {
+				if options.IncludeBase {
+					svs = append(svs, sv)
+				}
+				return options.sorted(svs), nil
+			}
+			if !options.Until.IsZero() && created.After(options.Until) {
+				continue
+			}
+			svs = append(svs, sv)
+			if options.MaxCount > 0 && len(svs) == options.MaxCount {
+				return options.sorted(svs), nil
+			}
+		}
+
+		if resp.Meta.Pagination.CurrentPage >= resp.Meta.Pagination.TotalPages {
+			return options.sorted(svs), nil
+		}
+		q.Set("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
 	}
-	return svs, nil
 }
 
 // GetLatestStateVersion gets the latest state version for a given
@@ -356,7 +400,11 @@ func (c *Client) GetLatestStateVersion(organization, workspace string) (StateVer
 		return StateVersion{}, err
 	}
 
-	path := fmt.Sprintf("/api/v2/workspaces/%s/current-state-version", workspaceData.ID)
+	return c.currentStateVersion(workspaceData.ID)
+}
+
+func (c *Client) currentStateVersion(workspaceID string) (StateVersion, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/current-state-version", workspaceID)
 
 	type wrapper struct {
 		Data StateVersion `json:"data"`
@@ -395,7 +443,7 @@ func (c *Client) GetStateVersion(organization, workspace, stateVersion string) (
 }
 
 // DownloadState downloads the raw state file from Terraform Enterprise
-// Requires 2 requests:
+// Requires 2 requests, or 1 when found in the StateCache:
 // - GetStateVersion (1)
 // - download from HostedStateDownloadURL
 func (c *Client) DownloadState(organization, workspace, stateVersion string) ([]byte, error) {
@@ -407,7 +455,7 @@ func (c *Client) DownloadState(organization, workspace, stateVersion string) ([]
 }
 
 // DownloadLatestState downloads the raw state file from Terraform Enterprise
-// Requires 3 requests:
+// Requires 3 requests, or 2 when found in the StateCache:
 // - GetLatestStateVersion (2)
 // - download from HostedStateDownloadURL
 func (c *Client) DownloadLatestState(organization, workspace string) ([]byte, error) {
@@ -418,40 +466,49 @@ func (c *Client) DownloadLatestState(organization, workspace string) ([]byte, er
 	return c.downloadStateVersion(sv)
 }
 
-func (c *Client) downloadStateVersion(sv StateVersion) ([]byte, error) {
-	var resp *http.Response
-	err := withRetries(
-		func() error {
-			var err error
-			resp, err = c.client.Get(sv.Attributes.HostedStateDownloadURL)
-			if err != nil {
-				return err
-			}
+// DownloadParsedState downloads a state file from Terraform Enterprise and
+// parses it, see ParseState
+// Requires 2 requests:
+// - DownloadState (2)
+func (c *Client) DownloadParsedState(organization, workspace, stateVersion string) (*State, error) {
+	raw, err := c.DownloadState(organization, workspace, stateVersion)
+	if err != nil {
+		return nil, err
+	}
+	return ParseState(raw)
+}
 
-			if resp.StatusCode != 200 {
-				return ErrBadStatus
-			}
-			return nil
-		},
-		func(e error) bool {
-			if e == ErrBadStatus {
-				return true
-			}
-			if e, ok := e.(net.Error); ok && e.Timeout() {
-				// Retry timeouts
-				return true
-			}
-			return false
-		},
-		10,
-	)
+// DownloadLatestParsedState downloads the latest state file from Terraform
+// Enterprise and parses it, see ParseState
+// Requires 3 requests:
+// - DownloadLatestState (3)
+func (c *Client) DownloadLatestParsedState(organization, workspace string) (*State, error) {
+	raw, err := c.DownloadLatestState(organization, workspace)
 	if err != nil {
 		return nil, err
 	}
+	return ParseState(raw)
+}
+
+// downloadStateVersion downloads the raw state file of a state version,
+// going through the StateCache if set
+func (c *Client) downloadStateVersion(sv StateVersion) ([]byte, error) {
+	if c.StateCache != nil {
+		if raw, ok, err := c.StateCache.Get(sv.ID); err == nil && ok {
+			return raw, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.DownloadStateTo(context.Background(), sv, &buf); err != nil {
+		return nil, err
+	}
 
-	raw, err := ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
-	return raw, err
+	if c.StateCache != nil && sv.ID != "" {
+		// Failing to cache the state does not fail the download
+		c.StateCache.Put(sv.ID, buf.Bytes())
+	}
+	return buf.Bytes(), nil
 }
 
 func (c *Client) do(method string, path string, body io.Reader, query url.Values, recv interface{}) error {
@@ -466,14 +523,30 @@ func (c *Client) do(method string, path string, body io.Reader, query url.Values
 	}
 	parsed.RawQuery = query.Encode()
 
+	// The body is buffered so it can be sent again on retries. Only
+	// idempotent requests are retried, as a request failing with a server
+	// error may still have been carried out.
+	var payload []byte
+	if body != nil {
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return err
+		}
+	}
+
+	var status int
 	return withRetries(
 		func() error {
-			req, err := http.NewRequest(method, parsed.String(), body)
+			status = 0
+			var reqBody io.Reader
+			if payload != nil {
+				reqBody = bytes.NewReader(payload)
+			}
+			req, err := http.NewRequest(method, parsed.String(), reqBody)
 			if err != nil {
 				return err
 			}
 
-			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AtlasToken))
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AtlasToken.Reveal()))
 			req.Header.Add("Content-Type", "application/vnd.api+json")
 
 			resp, err := c.client.Do(req)
@@ -481,23 +554,33 @@ func (c *Client) do(method string, path string, body io.Reader, query url.Values
 				return err
 			}
 			defer resp.Body.Close()
+			status = resp.StatusCode
 
 			switch {
 			case resp.StatusCode == 401:
 				return ErrUnauthorized
 			case resp.StatusCode == 404:
 				return ErrNotFound
+			case resp.StatusCode == 409:
+				return ErrConflict
 			case resp.StatusCode > 299:
 				return ErrBadStatus
 			}
 
+			if recv == nil || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+
 			decoder := json.NewDecoder(resp.Body)
 			err = decoder.Decode(&recv)
 			return err
 		},
 		func(e error) bool {
+			if !idempotentMethod(method) {
+				return false
+			}
 			if e == ErrBadStatus {
-				return true
+				return retryableStatus(status)
 			}
 			if e, ok := e.(net.Error); ok && e.Timeout() {
 				// Retry timeouts
@@ -509,6 +592,50 @@ func (c *Client) do(method string, path string, body io.Reader, query url.Values
 	)
 }
 
+// listPages gets every page of a paginated collection
+func listPages[T any](c *Client, path string, query url.Values) ([]T, error) {
+	type wrapper struct {
+		PaginatedResponse
+		Data []T `json:"data"`
+	}
+
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+
+	items := []T{}
+	for {
+		var resp wrapper
+		if err := c.do("GET", path, nil, q, &resp); err != nil {
+			return []T{}, err
+		}
+		items = append(items, resp.Data...)
+
+		if resp.Meta.Pagination.CurrentPage >= resp.Meta.Pagination.TotalPages {
+			return items, nil
+		}
+		q.Set("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
+	}
+}
+
+// idempotentMethod reports whether requests with the given method can be
+// sent again without side effects
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryableStatus reports whether a request which failed with the given
+// status may succeed later: server errors and rate limiting, but not client
+// errors such as validation failures
+func retryableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
 func withRetries(f func() error, shouldRetry func(e error) bool, attempts int) error {
 	interval := 500 * time.Millisecond
 	var err error
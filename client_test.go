@@ -6,7 +6,15 @@ package tfe
 
 import (
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 var testEnableLive = flag.Bool("enable-live", false, "enable tests that really call TFE (GETs only)")
@@ -148,3 +156,163 @@ func BenchmarkDownloadStateVersionLatest(b *testing.B) {
 		}
 	}
 }
+
+func TestListStateVersionsWithOptions(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		if page == 0 {
+			page = 1
+		}
+		if size := r.URL.Query().Get("page[size]"); size != "2" {
+			t.Errorf("got page size %q", size)
+		}
+
+		// 3 pages of 2 state versions, one per day, newest first
+		data := []string{}
+		for i := 0; i < 2; i++ {
+			n := (page-1)*2 + i
+			created := time.Date(2020, 1, 10-n, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+			data = append(data, fmt.Sprintf(`{"id": "sv-%d", "attributes": {"created-at": %q}}`, n, created))
+		}
+		fmt.Fprintf(w, `{"data": [%s], "meta": {"pagination": {"current-page": %d, "total-pages": 3}}}`, strings.Join(data, ","), page)
+	}))
+	defer server.Close()
+
+	c := New("token", server.URL)
+	ids := func(svs []StateVersion) string {
+		s := []string{}
+		for _, sv := range svs {
+			s = append(s, sv.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	svs, err := c.ListStateVersionsWithOptions("org", "ws", ListStateVersionsOptions{
+		PageSize: 2,
+		Since:    time.Date(2020, 1, 8, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(svs); got != "sv-1,sv-2" || requests != 2 {
+		t.Errorf("got %s in %d requests", got, requests)
+	}
+
+	requests = 0
+	svs, err = c.ListStateVersionsWithOptions("org", "ws", ListStateVersionsOptions{PageSize: 2, MaxCount: 3, OldestFirst: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(svs); got != "sv-2,sv-1,sv-0" || requests != 2 {
+		t.Errorf("got %s in %d requests", got, requests)
+	}
+}
+
+func TestDoRetriesWithBody(t *testing.T) {
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"id": "varset-1"}}`)
+	}))
+	defer server.Close()
+
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	c := New("token", server.URL)
+	if err := c.do(http.MethodPut, "/api/v2/varsets", strings.NewReader(`{"data": {}}`), nil, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != `{"data": {}}` || bodies[1] != bodies[0] {
+		t.Errorf("got bodies %q", bodies)
+	}
+	if resp.Data.ID != "varset-1" {
+		t.Errorf("got %+v", resp)
+	}
+}
+
+func TestDoDoesNotRetry(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		status int
+	}{
+		// A failed POST may still have been carried out
+		{http.MethodPost, http.StatusBadGateway},
+		{http.MethodPatch, http.StatusServiceUnavailable},
+		// Client errors don't go away by themselves
+		{http.MethodGet, http.StatusUnprocessableEntity},
+		{http.MethodPost, http.StatusUnprocessableEntity},
+	} {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(tc.status)
+		}))
+
+		err := New("token", server.URL).do(tc.method, "/api/v2/varsets", strings.NewReader(`{"data": {}}`), nil, nil)
+		server.Close()
+		if err != ErrBadStatus || requests != 1 {
+			t.Errorf("%s %d: got %v after %d requests", tc.method, tc.status, err, requests)
+		}
+	}
+}
+
+func TestDoSkipsDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/no-content" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, "not json")
+	}))
+	defer server.Close()
+
+	c := New("token", server.URL)
+	var resp struct{}
+	if err := c.do(http.MethodDelete, "/no-content", nil, nil, &resp); err != nil {
+		t.Errorf("204: %v", err)
+	}
+	if err := c.do(http.MethodDelete, "/ignored", nil, nil, nil); err != nil {
+		t.Errorf("nil recv: %v", err)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") != "x" {
+			t.Errorf("query not kept: %s", r.URL.RawQuery)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		if page == 0 {
+			page = 1
+		}
+		fmt.Fprintf(w, `{"data": [{"id": "item-%d"}], "meta": {"pagination": {"current-page": %d, "total-pages": 3}}}`, page, page)
+	}))
+	defer server.Close()
+
+	query := url.Values{"filter": {"x"}}
+	items, err := listPages[RelationshipData](New("token", server.URL), "/items", query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if strings.Join(ids, ",") != "item-1,item-2,item-3" {
+		t.Errorf("got %v", ids)
+	}
+	if len(query) != 1 {
+		t.Errorf("caller query modified: %v", query)
+	}
+}
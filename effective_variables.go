@@ -0,0 +1,169 @@
+package tfe
+
+import "sort"
+
+// VariableScope is how a variable set is applied to a workspace
+type VariableScope string
+
+// Variable set scopes, from the most to the least specific
+const (
+	ScopeWorkspace VariableScope = "workspace"
+	ScopeProject   VariableScope = "project"
+	ScopeGlobal    VariableScope = "global"
+)
+
+// VariableSource describes where a variable definition comes from. An empty
+// VariableSetID means the variable is defined on the workspace itself.
+type VariableSource struct {
+	VariableSetID   string
+	VariableSetName string
+	Scope           VariableScope
+	Priority        bool
+}
+
+// VariableDefinition is a variable along with its source
+type VariableDefinition struct {
+	Variable Variable
+	Source   VariableSource
+}
+
+// EffectiveVariable is the definition of a variable that a run will see,
+// along with the lower precedence definitions it shadows, highest first
+type EffectiveVariable struct {
+	VariableDefinition
+	Shadowed []VariableDefinition
+}
+
+// EffectiveVariables resolves the variables a run of a workspace will see,
+// from the workspace variables and every variable set applied to it.
+// Variables are identified by their category and key, and the winning
+// definition is chosen following TFE precedence rules, from highest to lowest:
+// - priority variable sets
+// - workspace variables
+// - other variable sets
+// Among variable sets of the same priority, workspace scoped sets win over
+// project scoped sets, which win over global sets, and ties are broken by the
+// lexical order of the set names.
+// Requires 2+P+S requests, where P is the number of pages of variable sets
+// and S the number of variable sets:
+// - ListVariables (1)
+// - ListWorkspaceVariableSets (1+P)
+// - ListVariableSetVariables (S)
+func (c *Client) EffectiveVariables(workspaceID string) ([]EffectiveVariable, error) {
+	vars, err := c.ListVariables(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := []VariableDefinition{}
+	for _, v := range vars {
+		defs = append(defs, VariableDefinition{
+			Variable: v,
+			Source:   VariableSource{Scope: ScopeWorkspace},
+		})
+	}
+
+	sets, err := c.ListWorkspaceVariableSets(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, set := range sets {
+		setVars, err := c.ListVariableSetVariables(set.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		source := VariableSource{
+			VariableSetID:   set.ID,
+			VariableSetName: set.Attributes.Name,
+			Scope:           variableSetScope(set, workspaceID),
+			Priority:        set.Attributes.Priority,
+		}
+		for _, v := range setVars {
+			defs = append(defs, VariableDefinition{Variable: v, Source: source})
+		}
+	}
+
+	return resolveVariables(defs), nil
+}
+
+// variableSetScope returns how a variable set applies to a workspace
+func variableSetScope(set VariableSet, workspaceID string) VariableScope {
+	if set.Attributes.Global {
+		return ScopeGlobal
+	}
+	for _, id := range set.Relationships.IDs("workspaces") {
+		if id == workspaceID {
+			return ScopeWorkspace
+		}
+	}
+	return ScopeProject
+}
+
+// resolveVariables groups definitions by category and key, and orders each
+// group by precedence. The result is sorted by category and key.
+func resolveVariables(defs []VariableDefinition) []EffectiveVariable {
+	type key struct {
+		category string
+		key      string
+	}
+
+	groups := map[key][]VariableDefinition{}
+	for _, d := range defs {
+		k := key{d.Variable.Attributes.Category, d.Variable.Attributes.Key}
+		groups[k] = append(groups[k], d)
+	}
+
+	effective := make([]EffectiveVariable, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return precedes(group[i].Source, group[j].Source)
+		})
+		effective = append(effective, EffectiveVariable{
+			VariableDefinition: group[0],
+			Shadowed:           group[1:],
+		})
+	}
+
+	sort.Slice(effective, func(i, j int) bool {
+		a, b := effective[i].Variable.Attributes, effective[j].Variable.Attributes
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Key < b.Key
+	})
+	return effective
+}
+
+// precedes reports whether a definition from source a takes precedence over
+// one from source b
+func precedes(a, b VariableSource) bool {
+	if ta, tb := precedenceTier(a), precedenceTier(b); ta != tb {
+		return ta < tb
+	}
+	if sa, sb := scopeRank(a.Scope), scopeRank(b.Scope); sa != sb {
+		return sa < sb
+	}
+	return a.VariableSetName < b.VariableSetName
+}
+
+func precedenceTier(s VariableSource) int {
+	switch {
+	case s.Priority:
+		return 0
+	case s.VariableSetID == "":
+		return 1
+	}
+	return 2
+}
+
+func scopeRank(s VariableScope) int {
+	switch s {
+	case ScopeWorkspace:
+		return 0
+	case ScopeProject:
+		return 1
+	}
+	return 2
+}
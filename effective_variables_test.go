@@ -0,0 +1,139 @@
+package tfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResolveVariables(t *testing.T) {
+	def := func(key, category, value string, source VariableSource) VariableDefinition {
+		return VariableDefinition{
+			Variable: Variable{Attributes: VariableAttributes{Key: key, Category: category, Value: value}},
+			Source:   source,
+		}
+	}
+
+	workspace := VariableSource{Scope: ScopeWorkspace}
+	global := VariableSource{VariableSetID: "varset-1", VariableSetName: "a-global", Scope: ScopeGlobal}
+	project := VariableSource{VariableSetID: "varset-2", VariableSetName: "z-project", Scope: ScopeProject}
+	projectB := VariableSource{VariableSetID: "varset-3", VariableSetName: "b-project", Scope: ScopeProject}
+	priority := VariableSource{VariableSetID: "varset-4", VariableSetName: "prio", Scope: ScopeGlobal, Priority: true}
+
+	got := resolveVariables([]VariableDefinition{
+		def("AWS_REGION", CategoryEnv, "global", global),
+		def("AWS_REGION", CategoryEnv, "workspace", workspace),
+		def("AWS_REGION", CategoryEnv, "project", project),
+		def("region", CategoryTerraform, "global", global),
+		def("region", CategoryTerraform, "project-z", project),
+		def("region", CategoryTerraform, "project-b", projectB),
+		def("AWS_SECRET", CategoryEnv, "workspace", workspace),
+		def("AWS_SECRET", CategoryEnv, "priority", priority),
+	})
+
+	want := []struct {
+		key, value string
+		shadowed   []string
+	}{
+		{"AWS_REGION", "workspace", []string{"project", "global"}},
+		{"AWS_SECRET", "priority", []string{"workspace"}},
+		{"region", "project-b", []string{"project-z", "global"}},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Variable.Attributes.Key != w.key || g.Variable.Attributes.Value != w.value {
+			t.Errorf("%d: got %s=%s, want %s=%s", i, g.Variable.Attributes.Key, g.Variable.Attributes.Value, w.key, w.value)
+		}
+		if len(g.Shadowed) != len(w.shadowed) {
+			t.Errorf("%s: got %d shadowed, want %d", w.key, len(g.Shadowed), len(w.shadowed))
+			continue
+		}
+		for j, s := range w.shadowed {
+			if v := g.Shadowed[j].Variable.Attributes.Value; v != s {
+				t.Errorf("%s: shadowed %d got %s, want %s", w.key, j, v, s)
+			}
+		}
+	}
+}
+
+func TestEffectiveVariables(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workspaces/ws-1/vars", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "var-1", "attributes": {"key": "region", "value": "workspace", "category": "terraform"}},
+			{"id": "var-2", "attributes": {"key": "AWS_SECRET", "value": "workspace", "category": "env"}}
+		]}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/varsets", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "varset-1", "attributes": {"name": "defaults", "global": true}},
+			{"id": "varset-2", "attributes": {"name": "aws", "priority": true},
+			 "relationships": {"workspaces": {"data": [{"id": "ws-1", "type": "workspaces"}]}}},
+			{"id": "varset-3", "attributes": {"name": "team"},
+			 "relationships": {"projects": {"data": [{"id": "prj-1", "type": "projects"}]}}}
+		], "meta": {"pagination": {"current-page": 1, "total-pages": 1}}}`)
+	})
+	for id, body := range map[string]string{
+		"varset-1": `{"id": "var-3", "attributes": {"key": "region", "value": "global", "category": "terraform"}}`,
+		"varset-2": `{"id": "var-4", "attributes": {"key": "AWS_SECRET", "value": "priority", "category": "env"}}`,
+		"varset-3": `{"id": "var-5", "attributes": {"key": "owner", "value": "project", "category": "terraform"}}`,
+	} {
+		body := body
+		mux.HandleFunc("/api/v2/varsets/"+id+"/relationships/vars", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": [%s], "meta": {"pagination": {"current-page": 1, "total-pages": 1}}}`, body)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	c := New("token", server.URL)
+
+	vars, err := c.EffectiveVariables("ws-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Key, Value, Set string
+		Scope           VariableScope
+		Shadowed        int
+	}
+	got := []result{}
+	for _, v := range vars {
+		got = append(got, result{v.Variable.Attributes.Key, v.Variable.Attributes.Value, v.Source.VariableSetID, v.Source.Scope, len(v.Shadowed)})
+	}
+	want := []result{
+		{"AWS_SECRET", "priority", "varset-2", ScopeWorkspace, 1},
+		{"owner", "project", "varset-3", ScopeProject, 0},
+		{"region", "workspace", "", ScopeWorkspace, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := c.EffectiveVariables("missing"); err != ErrWorkspaceNotFound {
+		t.Errorf("missing workspace: expected ErrWorkspaceNotFound, got %v", err)
+	}
+}
+
+func TestEffectiveVariablesSetNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workspaces/ws-1/vars", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/varsets", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "varset-1", "attributes": {"global": true}}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The variable set was deleted while listing
+	if _, err := New("token", server.URL).EffectiveVariables("ws-1"); err != ErrVariableSetNotFound {
+		t.Errorf("expected ErrVariableSetNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package tfe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// InventoryResource is a resource managed by a workspace
+type InventoryResource struct {
+	Workspace   string
+	WorkspaceID string
+	Module      string
+	Mode        string
+	Type        string
+	Name        string
+	Address     string
+	Provider    string
+	Instances   int
+}
+
+// InventoryOptions controls ScanInventory
+type InventoryOptions struct {
+	// Concurrency is the number of workspaces scanned in parallel, it
+	// defaults to 4
+	Concurrency int
+
+	// ResourceTypes restricts the inventory to resources of these types,
+	// e.g. aws_iam_role. All resources are included when empty.
+	ResourceTypes []string
+
+	// FromState always reads resources from the current state of workspaces.
+	// Otherwise the workspace resources endpoint is used when available,
+	// which avoids downloading state. Either way only managed resources are
+	// listed.
+	FromState bool
+
+	// Skip holds the names of workspaces to leave out, typically those
+	// already reported to OnWorkspace by an interrupted scan
+	Skip map[string]bool
+
+	// OnWorkspace, when set, is called with the resources of each workspace
+	// as soon as it is scanned, one workspace at a time. It can be used to
+	// persist progress and resume a scan later through Skip. Returning an
+	// error stops the scan.
+	OnWorkspace func(workspace Workspace, resources []InventoryResource) error
+}
+
+// ScanInventory lists the resources managed by every workspace of an
+// organization, from the workspace resources endpoint, or from their current
+// state on Terraform Enterprise versions without it or when
+// options.FromState is set. Workspaces without state are reported with no
+// resources. Providers are reported by source, e.g. hashicorp/aws.
+// On error, the resources of the workspaces scanned so far are returned along
+// with the error. The result is sorted by workspace and address.
+// Requires P+W*R requests, where P is the number of pages of workspaces, W
+// the number of workspaces and R either the number of pages of resources, or
+// 2 when reading state:
+// - ListWorkspaces (P)
+// - ListWorkspaceResources (R)
+// - /api/v2/workspaces/:workspaceID/current-state-version (1)
+// - download from HostedStateDownloadURL (1)
+func (c *Client) ScanInventory(ctx context.Context, organization string, options InventoryOptions) ([]InventoryResource, error) {
+	workspaces, err := c.ListWorkspaces(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	types := map[string]bool{}
+	for _, t := range options.ResourceTypes {
+		types[t] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		workspace Workspace
+		resources []InventoryResource
+		err       error
+	}
+
+	jobs := make(chan Workspace)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ws := range jobs {
+				resources, err := c.workspaceInventory(ctx, ws, types, options.FromState)
+				results <- result{ws, resources, err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, ws := range workspaces {
+			if options.Skip[ws.Attributes.Name] {
+				continue
+			}
+			select {
+			case jobs <- ws:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	inventory := []InventoryResource{}
+	var firstErr error
+	for r := range results {
+		if firstErr != nil {
+			continue
+		}
+		if r.err == nil && options.OnWorkspace != nil {
+			r.err = options.OnWorkspace(r.workspace, r.resources)
+		}
+		if r.err != nil {
+			firstErr = fmt.Errorf("workspace %s: %w", r.workspace.Attributes.Name, r.err)
+			cancel()
+			continue
+		}
+		inventory = append(inventory, r.resources...)
+	}
+
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].Workspace != inventory[j].Workspace {
+			return inventory[i].Workspace < inventory[j].Workspace
+		}
+		return inventory[i].Address < inventory[j].Address
+	})
+	return inventory, firstErr
+}
+
+// workspaceInventory lists the resources of a workspace, restricted to the
+// given types when not empty
+func (c *Client) workspaceInventory(ctx context.Context, ws Workspace, types map[string]bool, fromState bool) ([]InventoryResource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !fromState {
+		wrs, err := c.ListWorkspaceResources(ws.ID)
+		if err == nil {
+			return workspaceResourcesInventory(ws, wrs, types), nil
+		}
+		if err != ErrNotFound {
+			return nil, err
+		}
+	}
+
+	sv, err := c.currentStateVersion(ws.ID)
+	if err == ErrStateVersionNotFound {
+		return []InventoryResource{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := c.DownloadStateTo(ctx, sv, &buf); err != nil {
+		return nil, err
+	}
+	state, err := ParseState(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []InventoryResource{}
+	for _, r := range state.Resources {
+		// Data sources are read, not managed, by the workspace
+		if r.Mode == "data" || len(types) > 0 && !types[r.Type] {
+			continue
+		}
+		resources = append(resources, InventoryResource{
+			Workspace:   ws.Attributes.Name,
+			WorkspaceID: ws.ID,
+			Module:      r.Module,
+			Mode:        r.Mode,
+			Type:        r.Type,
+			Name:        r.Name,
+			Address:     r.Address(),
+			Provider:    providerSource(r.Provider),
+			Instances:   len(r.Instances),
+		})
+	}
+	return resources, nil
+}
+
+// workspaceResourcesInventory groups resource instances from the workspace
+// resources endpoint by resource
+func workspaceResourcesInventory(ws Workspace, wrs []WorkspaceResource, types map[string]bool) []InventoryResource {
+	resources := []InventoryResource{}
+	byAddress := map[string]int{}
+	for _, wr := range wrs {
+		if len(types) > 0 && !types[wr.Attributes.ProviderType] {
+			continue
+		}
+
+		sr := StateResource{
+			Module: wr.ModuleAddress(),
+			Mode:   "managed",
+			Type:   wr.Attributes.ProviderType,
+			Name:   wr.ResourceName(),
+		}
+		addr := sr.Address()
+		if i, ok := byAddress[addr]; ok {
+			resources[i].Instances++
+			continue
+		}
+
+		byAddress[addr] = len(resources)
+		resources = append(resources, InventoryResource{
+			Workspace:   ws.Attributes.Name,
+			WorkspaceID: ws.ID,
+			Module:      sr.Module,
+			Mode:        sr.Mode,
+			Type:        sr.Type,
+			Name:        sr.Name,
+			Address:     addr,
+			Provider:    wr.Attributes.Provider,
+			Instances:   1,
+		})
+	}
+	return resources
+}
+
+// providerSource extracts the provider source from a provider address, e.g.
+// hashicorp/aws from module.a.provider["registry.terraform.io/hashicorp/aws"].west
+// Unrecognized addresses are returned unchanged.
+func providerSource(addr string) string {
+	start := strings.Index(addr, `provider["`)
+	if start < 0 {
+		return addr
+	}
+	source := addr[start+len(`provider["`):]
+	end := strings.Index(source, `"]`)
+	if end < 0 {
+		return addr
+	}
+	source = source[:end]
+	if parts := strings.Split(source, "/"); len(parts) == 3 {
+		return parts[1] + "/" + parts[2]
+	}
+	return source
+}
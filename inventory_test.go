@@ -0,0 +1,117 @@
+package tfe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestScanInventory(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/organizations/org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "ws-1", "attributes": {"name": "network"}},
+			{"id": "ws-2", "attributes": {"name": "empty"}},
+			{"id": "ws-3", "attributes": {"name": "done"}}
+		]}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": {"id": "sv-1", "attributes": {"hosted-state-download-url": "%s/state"}}}`, server.URL)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-2/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-3/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("skipped workspace was scanned")
+	})
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version": 4, "resources": [
+			{"module": "module.iam", "mode": "managed", "type": "aws_iam_role", "name": "ci", "provider": "aws", "instances": [{}, {}]},
+			{"mode": "data", "type": "aws_iam_role", "name": "admin", "provider": "aws", "instances": [{}]},
+			{"mode": "managed", "type": "aws_vpc", "name": "main", "provider": "aws", "instances": [{}]}
+		]}`)
+	})
+
+	scanned := []string{}
+	inventory, err := New("token", server.URL).ScanInventory(context.Background(), "org", InventoryOptions{
+		ResourceTypes: []string{"aws_iam_role"},
+		Skip:          map[string]bool{"done": true},
+		OnWorkspace: func(ws Workspace, resources []InventoryResource) error {
+			scanned = append(scanned, ws.Attributes.Name)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []InventoryResource{{
+		Workspace:   "network",
+		WorkspaceID: "ws-1",
+		Module:      "module.iam",
+		Mode:        "managed",
+		Type:        "aws_iam_role",
+		Name:        "ci",
+		Address:     "module.iam.aws_iam_role.ci",
+		Provider:    "aws",
+		Instances:   2,
+	}}
+	if !reflect.DeepEqual(inventory, want) {
+		t.Errorf("got %+v", inventory)
+	}
+	if len(scanned) != 2 {
+		t.Errorf("got scanned workspaces %v", scanned)
+	}
+}
+
+func TestScanInventoryWorkspaceResources(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/organizations/org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "ws-1", "attributes": {"name": "media"}}]}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/resources", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "wsr-1", "attributes": {"address": "media_bucket.aws_s3_bucket.this[0]", "name": "this[0]", "name-index": "0", "module": "media_bucket", "provider": "hashicorp/aws", "provider-type": "aws_s3_bucket"}},
+			{"id": "wsr-2", "attributes": {"address": "media_bucket.aws_s3_bucket.this[1]", "name": "this[1]", "name-index": "1", "module": "media_bucket", "provider": "hashicorp/aws", "provider-type": "aws_s3_bucket"}},
+			{"id": "wsr-3", "attributes": {"address": "random_pet.name", "name": "name", "module": "root", "provider": "hashicorp/random", "provider-type": "random_pet"}}
+		], "meta": {"pagination": {"current-page": 1, "total-pages": 1, "total-count": 3}}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("state read despite the workspace resources endpoint")
+	})
+
+	c := New("token", server.URL)
+	inventory, err := c.ScanInventory(context.Background(), "org", InventoryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []InventoryResource{
+		{Workspace: "media", WorkspaceID: "ws-1", Module: "module.media_bucket", Mode: "managed", Type: "aws_s3_bucket", Name: "this", Address: "module.media_bucket.aws_s3_bucket.this", Provider: "hashicorp/aws", Instances: 2},
+		{Workspace: "media", WorkspaceID: "ws-1", Mode: "managed", Type: "random_pet", Name: "name", Address: "random_pet.name", Provider: "hashicorp/random", Instances: 1},
+	}
+	if !reflect.DeepEqual(inventory, want) {
+		t.Errorf("got %+v", inventory)
+	}
+
+	addrs, err := c.ListWorkspaceResourceAddresses("ws-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"module.media_bucket.aws_s3_bucket.this[0]", "module.media_bucket.aws_s3_bucket.this[1]", "random_pet.name"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("got addresses %v", addrs)
+	}
+
+	if n, err := c.CountWorkspaceResources("ws-1"); err != nil || n != 3 {
+		t.Errorf("got count %d, %v", n, err)
+	}
+}
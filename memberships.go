@@ -0,0 +1,150 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrMembershipNotFound is returned when an organization membership does
+// not exist or is not visible to the token
+var ErrMembershipNotFound = errors.New("Organization membership not found")
+
+// ListOrganizationMemberships lists the members of an organization,
+// including invited ones
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/organization-memberships
+func (c *Client) ListOrganizationMemberships(organization string, options ListOrganizationMembershipsOptions) ([]OrganizationMembership, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/organization-memberships", organization)
+
+	q := url.Values{}
+	if options.Query != "" {
+		q.Set("q", options.Query)
+	}
+	if len(options.Emails) > 0 {
+		q.Set("filter[email]", strings.Join(options.Emails, ","))
+	}
+	if options.Status != "" {
+		q.Set("filter[status]", options.Status)
+	}
+
+	memberships, err := listPages[OrganizationMembership](c, path, q)
+	if err == ErrNotFound {
+		return memberships, ErrOrganizationNotFound
+	}
+	return memberships, err
+}
+
+// GetOrganizationMembership gets a specific organization membership
+// Requires 1 request:
+// - /api/v2/organization-memberships/:membershipID
+func (c *Client) GetOrganizationMembership(membershipID string) (OrganizationMembership, error) {
+	path := fmt.Sprintf("/api/v2/organization-memberships/%s", membershipID)
+
+	type wrapper struct {
+		Data OrganizationMembership `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OrganizationMembership{}, ErrMembershipNotFound
+		}
+		return OrganizationMembership{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// ListMembershipTeams lists the teams of an organization member, e.g. to
+// record them before removing the member
+// Requires 1 request:
+// - /api/v2/organization-memberships/:membershipID?include=teams
+func (c *Client) ListMembershipTeams(membershipID string) ([]Team, error) {
+	path := fmt.Sprintf("/api/v2/organization-memberships/%s", membershipID)
+	q := url.Values{}
+	q.Set("include", "teams")
+
+	type wrapper struct {
+		Data     OrganizationMembership `json:"data"`
+		Included []Team                 `json:"included"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, q, &resp); err != nil {
+		if err == ErrNotFound {
+			return nil, ErrMembershipNotFound
+		}
+		return nil, err
+	}
+
+	teams := []Team{}
+	for _, t := range resp.Included {
+		if t.Type == "teams" {
+			teams = append(teams, t)
+		}
+	}
+	return teams, nil
+}
+
+// InviteMember invites a user to an organization by email, adding them to
+// the given teams once they accept. At least one team is required.
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/organization-memberships
+func (c *Client) InviteMember(organization, email string, teamIDs ...string) (OrganizationMembership, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/organization-memberships", organization)
+
+	type attributes struct {
+		Email string `json:"email"`
+	}
+	type payload struct {
+		Type          string        `json:"type"`
+		Attributes    attributes    `json:"attributes"`
+		Relationships Relationships `json:"relationships"`
+	}
+	type wrapper struct {
+		Data payload `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload{
+		Type:          "organization-memberships",
+		Attributes:    attributes{Email: email},
+		Relationships: Relationships{"teams": toMany("teams", teamIDs)},
+	}})
+	if err != nil {
+		return OrganizationMembership{}, err
+	}
+
+	type wrapperResp struct {
+		Data OrganizationMembership `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OrganizationMembership{}, ErrOrganizationNotFound
+		}
+		return OrganizationMembership{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteMembership removes a member from an organization, and from all of
+// its teams, or cancels an invitation
+// Requires 1 request:
+// - DELETE /api/v2/organization-memberships/:membershipID
+func (c *Client) DeleteMembership(membershipID string) error {
+	path := fmt.Sprintf("/api/v2/organization-memberships/%s", membershipID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrMembershipNotFound
+		}
+		return err
+	}
+	return nil
+}
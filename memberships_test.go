@@ -0,0 +1,59 @@
+package tfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListOrganizationMemberships(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("filter[email]") != "a@example.com,b@example.com" || q.Get("filter[status]") != MembershipActive {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if q.Get("page[number]") == "" {
+			fmt.Fprint(w, `{"data": [{"id": "ou-1", "attributes": {"email": "a@example.com", "status": "active"}, "relationships": {"user": {"data": {"id": "user-1", "type": "users"}}, "teams": {"data": [{"id": "team-1", "type": "teams"}]}}}], "meta": {"pagination": {"current-page": 1, "total-pages": 2}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "ou-2", "attributes": {"email": "b@example.com", "status": "active"}}], "meta": {"pagination": {"current-page": 2, "total-pages": 2}}}`)
+	}))
+	defer server.Close()
+
+	memberships, err := New("token", server.URL).ListOrganizationMemberships("org", ListOrganizationMembershipsOptions{
+		Emails: []string{"a@example.com", "b@example.com"},
+		Status: MembershipActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memberships) != 2 {
+		t.Fatalf("got %d memberships", len(memberships))
+	}
+	if memberships[0].UserID() != "user-1" || !reflect.DeepEqual(memberships[0].TeamIDs(), []string{"team-1"}) {
+		t.Errorf("got %+v", memberships[0])
+	}
+}
+
+func TestListMembershipTeams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/organization-memberships/ou-1" || r.URL.Query().Get("include") != "teams" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"data": {"id": "ou-1"}, "included": [
+			{"id": "user-1", "type": "users", "attributes": {"username": "alice"}},
+			{"id": "team-1", "type": "teams", "attributes": {"name": "devs"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	teams, err := New("token", server.URL).ListMembershipTeams("ou-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != 1 || teams[0].Attributes.Name != "devs" {
+		t.Errorf("got %+v", teams)
+	}
+}
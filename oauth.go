@@ -0,0 +1,217 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrOAuthClientNotFound is returned when an OAuth client does not exist or
+// is not visible to the token
+var ErrOAuthClientNotFound = errors.New("OAuth client not found")
+
+// ErrOAuthTokenNotFound is returned when an OAuth client has no token, i.e.
+// its connection to the VCS provider was never authorized
+var ErrOAuthTokenNotFound = errors.New("OAuth token not found")
+
+// ErrAmbiguousOAuthClient is returned when several OAuth clients match a VCS
+// provider name
+var ErrAmbiguousOAuthClient = errors.New("Several OAuth clients match")
+
+// defaultVCSURLs holds the HTTP and API URLs of hosted VCS providers
+var defaultVCSURLs = map[string][2]string{
+	ServiceProviderGitHub:              {"https://github.com", "https://api.github.com"},
+	ServiceProviderGitLab:              {"https://gitlab.com", "https://gitlab.com/api/v4"},
+	ServiceProviderBitbucket:           {"https://bitbucket.org", "https://api.bitbucket.org"},
+	ServiceProviderAzureDevOpsServices: {"https://dev.azure.com", "https://dev.azure.com"},
+}
+
+// ListOAuthClients lists the VCS provider connections of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/oauth-clients
+func (c *Client) ListOAuthClients(organization string) ([]OAuthClient, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/oauth-clients", organization)
+	clients, err := listPages[OAuthClient](c, path, nil)
+	if err == ErrNotFound {
+		return clients, ErrOrganizationNotFound
+	}
+	return clients, err
+}
+
+// GetOAuthClient gets a specific OAuth client
+// Requires 1 request:
+// - /api/v2/oauth-clients/:oauthClientID
+func (c *Client) GetOAuthClient(oauthClientID string) (OAuthClient, error) {
+	path := fmt.Sprintf("/api/v2/oauth-clients/%s", oauthClientID)
+
+	type wrapper struct {
+		Data OAuthClient `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OAuthClient{}, ErrOAuthClientNotFound
+		}
+		return OAuthClient{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateOAuthClient connects an organization to a VCS provider. When
+// options.OAuthToken is set the connection is authorized right away and
+// the client comes with an OAuth token, otherwise it must be authorized from
+// the UI.
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/oauth-clients
+func (c *Client) CreateOAuthClient(organization string, options CreateOAuthClientOptions) (OAuthClient, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/oauth-clients", organization)
+
+	httpURL, apiURL := options.HTTPURL, options.APIURL
+	if defaults, ok := defaultVCSURLs[options.ServiceProvider]; ok {
+		if httpURL == "" {
+			httpURL = defaults[0]
+		}
+		if apiURL == "" {
+			apiURL = defaults[1]
+		}
+	}
+
+	type attributes struct {
+		Name             string `json:"name,omitempty"`
+		ServiceProvider  string `json:"service-provider"`
+		HTTPURL          string `json:"http-url"`
+		APIURL           string `json:"api-url"`
+		OAuthTokenString Secret `json:"oauth-token-string,omitempty"`
+		Key              string `json:"key,omitempty"`
+		Secret           Secret `json:"secret,omitempty"`
+		PrivateKey       Secret `json:"private-key,omitempty"`
+		RSAPublicKey     string `json:"rsa-public-key,omitempty"`
+	}
+	type payload struct {
+		Type       string     `json:"type"`
+		Attributes attributes `json:"attributes"`
+	}
+	type wrapper struct {
+		Data payload `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload{
+		Type: "oauth-clients",
+		Attributes: attributes{
+			Name:             options.Name,
+			ServiceProvider:  options.ServiceProvider,
+			HTTPURL:          httpURL,
+			APIURL:           apiURL,
+			OAuthTokenString: options.OAuthToken,
+			Key:              options.Key,
+			Secret:           options.Secret,
+			PrivateKey:       options.PrivateKey,
+			RSAPublicKey:     options.RSAPublicKey,
+		},
+	}})
+	if err != nil {
+		return OAuthClient{}, err
+	}
+
+	type wrapperResp struct {
+		Data OAuthClient `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OAuthClient{}, ErrOrganizationNotFound
+		}
+		return OAuthClient{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteOAuthClient disconnects a VCS provider, which also disconnects the
+// workspaces using it
+// Requires 1 request:
+// - DELETE /api/v2/oauth-clients/:oauthClientID
+func (c *Client) DeleteOAuthClient(oauthClientID string) error {
+	path := fmt.Sprintf("/api/v2/oauth-clients/%s", oauthClientID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrOAuthClientNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListOAuthTokens lists the OAuth tokens of an OAuth client
+// Requires P requests, where P is the number of pages
+// - /api/v2/oauth-clients/:oauthClientID/oauth-tokens
+func (c *Client) ListOAuthTokens(oauthClientID string) ([]OAuthToken, error) {
+	path := fmt.Sprintf("/api/v2/oauth-clients/%s/oauth-tokens", oauthClientID)
+	tokens, err := listPages[OAuthToken](c, path, nil)
+	if err == ErrNotFound {
+		return tokens, ErrOAuthClientNotFound
+	}
+	return tokens, err
+}
+
+// FindOAuthTokenID resolves a VCS provider to the ID of the OAuth token to
+// set as CreateWorkspaceOptions.VCSOauthKeyID. The provider is matched,
+// case-insensitively, against the name, service provider and service
+// provider display name of the OAuth clients of the organization, e.g.
+// "github", "GitLab.com" or the name given to the client. It fails with
+// ErrAmbiguousOAuthClient when several clients match.
+// Requires P+1 requests, where P is the number of pages of OAuth clients:
+// - ListOAuthClients (P)
+// - ListOAuthTokens (1)
+func (c *Client) FindOAuthTokenID(organization, provider string) (string, error) {
+	clients, err := c.ListOAuthClients(organization)
+	if err != nil {
+		return "", err
+	}
+
+	matches := []OAuthClient{}
+	for _, oc := range clients {
+		for _, name := range []string{oc.Attributes.Name, oc.Attributes.ServiceProvider, oc.Attributes.ServiceProviderDisplayName} {
+			if name != "" && strings.EqualFold(name, provider) {
+				matches = append(matches, oc)
+				break
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrOAuthClientNotFound, provider)
+	case 1:
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, oc := range matches {
+			ids = append(ids, oc.ID)
+		}
+		return "", fmt.Errorf("%w %s: %s", ErrAmbiguousOAuthClient, provider, strings.Join(ids, ", "))
+	}
+
+	tokens, err := c.ListOAuthTokens(matches[0].ID)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", fmt.Errorf("%w: OAuth client %s", ErrOAuthTokenNotFound, matches[0].ID)
+	}
+	return tokens[0].ID, nil
+}
+
+// ListGitHubAppInstallations lists the installations of the Terraform Cloud
+// GitHub App available to the user owning the token. Their IDs are set as
+// CreateWorkspaceOptions.VCSGHAInstallationID.
+// Requires P requests, where P is the number of pages
+// - /api/v2/github-app/installations
+func (c *Client) ListGitHubAppInstallations() ([]GHAInstallation, error) {
+	return listPages[GHAInstallation](c, "/api/v2/github-app/installations", nil)
+}
@@ -0,0 +1,75 @@
+package tfe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFindOAuthTokenID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations/org/oauth-clients":
+			fmt.Fprint(w, `{"data": [
+				{"id": "oc-1", "attributes": {"name": "main", "service-provider": "github", "service-provider-display-name": "GitHub"}},
+				{"id": "oc-2", "attributes": {"name": "infra", "service-provider": "gitlab_hosted", "service-provider-display-name": "GitLab.com"}},
+				{"id": "oc-3", "attributes": {"name": "apps", "service-provider": "gitlab_hosted", "service-provider-display-name": "GitLab.com"}}
+			]}`)
+		case "/api/v2/oauth-clients/oc-1/oauth-tokens":
+			fmt.Fprint(w, `{"data": [{"id": "ot-1"}]}`)
+		case "/api/v2/oauth-clients/oc-2/oauth-tokens":
+			fmt.Fprint(w, `{"data": []}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	c := New("token", server.URL)
+
+	if id, err := c.FindOAuthTokenID("org", "GitHub"); err != nil || id != "ot-1" {
+		t.Errorf("got %q, %v", id, err)
+	}
+	if _, err := c.FindOAuthTokenID("org", "gitlab_hosted"); !errors.Is(err, ErrAmbiguousOAuthClient) {
+		t.Errorf("got %v, want ErrAmbiguousOAuthClient", err)
+	}
+	if _, err := c.FindOAuthTokenID("org", "infra"); !errors.Is(err, ErrOAuthTokenNotFound) {
+		t.Errorf("got %v, want ErrOAuthTokenNotFound", err)
+	}
+	if _, err := c.FindOAuthTokenID("org", "bitbucket"); !errors.Is(err, ErrOAuthClientNotFound) {
+		t.Errorf("got %v, want ErrOAuthClientNotFound", err)
+	}
+}
+
+func TestCreateOAuthClient(t *testing.T) {
+	var got map[string]map[string]map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+		fmt.Fprint(w, `{"data": {"id": "oc-1"}}`)
+	}))
+	defer server.Close()
+
+	_, err := New("token", server.URL).CreateOAuthClient("org", CreateOAuthClientOptions{
+		ServiceProvider: ServiceProviderGitHub,
+		OAuthToken:      "ghp_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"service-provider":   "github",
+		"http-url":           "https://github.com",
+		"api-url":            "https://api.github.com",
+		"oauth-token-string": "ghp_secret",
+	}
+	attrs := got["data"]["attributes"]
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("got attributes %v", attrs)
+	}
+}
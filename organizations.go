@@ -0,0 +1,176 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrOrganizationNotFound is returned when an organization does not exist or
+// is not visible to the token
+var ErrOrganizationNotFound = errors.New("Organization not found")
+
+// organizationPayload is the writable subset of an organization
+type organizationPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name                   *string `json:"name,omitempty"`
+		Email                  *string `json:"email,omitempty"`
+		SessionTimeout         *int    `json:"session-timeout,omitempty"`
+		SessionRemember        *int    `json:"session-remember,omitempty"`
+		CollaboratorAuthPolicy *string `json:"collaborator-auth-policy,omitempty"`
+		CostEstimationEnabled  *bool   `json:"cost-estimation-enabled,omitempty"`
+		DefaultExecutionMode   *string `json:"default-execution-mode,omitempty"`
+	} `json:"attributes"`
+}
+
+// GetOrganization gets a specific organization
+// Requires 1 request:
+// - /api/v2/organizations/:organizationName
+func (c *Client) GetOrganization(organization string) (Organization, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s", organization)
+
+	type wrapper struct {
+		Data Organization `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Organization{}, ErrOrganizationNotFound
+		}
+		return Organization{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateOrganization creates a new organization, owned by the token owner
+// Requires 1 request:
+// - POST /api/v2/organizations
+func (c *Client) CreateOrganization(options CreateOrganizationOptions) (Organization, error) {
+	path := "/api/v2/organizations"
+
+	payload := organizationPayload{Type: "organizations"}
+	payload.Attributes.Name = &options.Name
+	payload.Attributes.Email = &options.Email
+	payload.Attributes.CostEstimationEnabled = &options.CostEstimationEnabled
+	if options.SessionTimeout != 0 {
+		payload.Attributes.SessionTimeout = &options.SessionTimeout
+	}
+	if options.SessionRemember != 0 {
+		payload.Attributes.SessionRemember = &options.SessionRemember
+	}
+	if options.CollaboratorAuthPolicy != "" {
+		payload.Attributes.CollaboratorAuthPolicy = &options.CollaboratorAuthPolicy
+	}
+	if options.DefaultExecutionMode != "" {
+		payload.Attributes.DefaultExecutionMode = &options.DefaultExecutionMode
+	}
+
+	return c.writeOrganization(http.MethodPost, path, payload)
+}
+
+// UpdateOrganization updates the settings of an organization
+// Requires 1 request:
+// - PATCH /api/v2/organizations/:organizationName
+func (c *Client) UpdateOrganization(organization string, options UpdateOrganizationOptions) (Organization, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s", organization)
+
+	payload := organizationPayload{Type: "organizations"}
+	payload.Attributes.Name = options.Name
+	payload.Attributes.Email = options.Email
+	payload.Attributes.SessionTimeout = options.SessionTimeout
+	payload.Attributes.SessionRemember = options.SessionRemember
+	payload.Attributes.CollaboratorAuthPolicy = options.CollaboratorAuthPolicy
+	payload.Attributes.CostEstimationEnabled = options.CostEstimationEnabled
+	payload.Attributes.DefaultExecutionMode = options.DefaultExecutionMode
+
+	return c.writeOrganization(http.MethodPatch, path, payload)
+}
+
+func (c *Client) writeOrganization(method, path string, payload organizationPayload) (Organization, error) {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return Organization{}, err
+	}
+
+	type wrapperResp struct {
+		Data Organization `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Organization{}, ErrOrganizationNotFound
+		}
+		return Organization{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteOrganization deletes an organization, along with all of its
+// workspaces, teams and state
+// Requires 1 request:
+// - DELETE /api/v2/organizations/:organizationName
+func (c *Client) DeleteOrganization(organization string) error {
+	path := fmt.Sprintf("/api/v2/organizations/%s", organization)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrOrganizationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetOrganizationEntitlements gets the features available to an
+// organization
+// Requires 1 request:
+// - /api/v2/organizations/:organizationName/entitlement-set
+func (c *Client) GetOrganizationEntitlements(organization string) (OrganizationEntitlements, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/entitlement-set", organization)
+
+	type wrapper struct {
+		Data OrganizationEntitlements `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OrganizationEntitlements{}, ErrOrganizationNotFound
+		}
+		return OrganizationEntitlements{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// GetOrganizationCapacity gets the number of pending and running runs of an
+// organization
+// Requires 1 request:
+// - /api/v2/organizations/:organizationName/capacity
+func (c *Client) GetOrganizationCapacity(organization string) (OrganizationCapacity, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/capacity", organization)
+
+	type wrapper struct {
+		Data OrganizationCapacity `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return OrganizationCapacity{}, ErrOrganizationNotFound
+		}
+		return OrganizationCapacity{}, err
+	}
+
+	return resp.Data, nil
+}
@@ -0,0 +1,150 @@
+package tfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCreateOrganization(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusCreated, `{"data": {"id": "acme", "attributes": {"name": "acme"}}}`)
+
+	org, err := New("token", server.URL).CreateOrganization(CreateOrganizationOptions{
+		Name:           "acme",
+		Email:          "ops@acme.test",
+		SessionTimeout: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.ID != "acme" {
+		t.Errorf("got %+v", org)
+	}
+
+	want := []recordedRequest{{
+		Method: http.MethodPost,
+		Path:   "/api/v2/organizations",
+		Body: map[string]interface{}{"data": map[string]interface{}{
+			"type": "organizations",
+			"attributes": map[string]interface{}{
+				"name":                    "acme",
+				"email":                   "ops@acme.test",
+				"session-timeout":         float64(60),
+				"cost-estimation-enabled": false,
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %+v\nwant %+v", *requests, want)
+	}
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK, `{"data": {"id": "acme"}}`)
+
+	email, costEstimation := "security@acme.test", false
+	if _, err := New("token", server.URL).UpdateOrganization("acme", UpdateOrganizationOptions{
+		Email:                 &email,
+		CostEstimationEnabled: &costEstimation,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unset options are left out, so that they are left untouched
+	want := []recordedRequest{{
+		Method: http.MethodPatch,
+		Path:   "/api/v2/organizations/acme",
+		Body: map[string]interface{}{"data": map[string]interface{}{
+			"type": "organizations",
+			"attributes": map[string]interface{}{
+				"email":                   "security@acme.test",
+				"cost-estimation-enabled": false,
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %+v\nwant %+v", *requests, want)
+	}
+}
+
+func TestOrganizationNotFound(t *testing.T) {
+	server, _ := recordingServer(t, http.StatusNotFound, `{"errors": [{"status": "404"}]}`)
+	c := New("token", server.URL)
+
+	name := "renamed"
+	for op, call := range map[string]func() error{
+		"get": func() error { _, err := c.GetOrganization("missing"); return err },
+		"update": func() error {
+			_, err := c.UpdateOrganization("missing", UpdateOrganizationOptions{Name: &name})
+			return err
+		},
+		"delete":       func() error { return c.DeleteOrganization("missing") },
+		"entitlements": func() error { _, err := c.GetOrganizationEntitlements("missing"); return err },
+		"capacity":     func() error { _, err := c.GetOrganizationCapacity("missing"); return err },
+	} {
+		if err := call(); err != ErrOrganizationNotFound {
+			t.Errorf("%s: expected ErrOrganizationNotFound, got %v", op, err)
+		}
+	}
+}
+
+func TestOrganizationEntitlementsAndCapacity(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/acme/entitlement-set", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"id": "org-1", "attributes": {"sentinel": true, "sso": true, "teams": false}}}`)
+	})
+	mux.HandleFunc("/api/v2/organizations/acme/capacity", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"id": "acme", "attributes": {"pending": 2, "running": 1}}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	c := New("token", server.URL)
+
+	entitlements, err := c.GetOrganizationEntitlements("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (OrganizationEntitlementsAttributes{Sentinel: true, SingleSignOn: true}); entitlements.Attributes != want {
+		t.Errorf("got entitlements %+v", entitlements.Attributes)
+	}
+
+	capacity, err := c.GetOrganizationCapacity("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (OrganizationCapacityAttributes{Pending: 2, Running: 1}); capacity.Attributes != want {
+		t.Errorf("got capacity %+v", capacity.Attributes)
+	}
+}
+
+func TestListOrganizations(t *testing.T) {
+	pages := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page[number]")
+		pages = append(pages, page)
+		if page == "" {
+			fmt.Fprint(w, `{"data": [{"id": "acme"}], "meta": {"pagination": {"current-page": 1, "total-pages": 2}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "globex"}], "meta": {"pagination": {"current-page": 2, "total-pages": 2}}}`)
+	}))
+	defer server.Close()
+
+	orgs, err := New("token", server.URL).ListOrganizations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for _, org := range orgs {
+		ids = append(ids, org.ID)
+	}
+	if want := []string{"acme", "globex"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got organizations %v", ids)
+	}
+	if want := []string{"", "2"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("got pages %q", pages)
+	}
+}
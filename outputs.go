@@ -0,0 +1,99 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Error Types
+var (
+	ErrStateVersionOutputNotFound = errors.New("State version output not found")
+	ErrSensitiveOutput            = errors.New("Sensitive output value was not returned, use GetStateVersionOutput")
+)
+
+// ListStateVersionOutputs lists the outputs of a state version, without the
+// values of sensitive outputs
+// Requires P requests, where P is the number of pages
+// - /api/v2/state-versions/:stateVersionID/outputs
+func (c *Client) ListStateVersionOutputs(stateVersionID string) ([]StateVersionOutput, error) {
+	path := fmt.Sprintf("/api/v2/state-versions/%s/outputs", stateVersionID)
+	outputs, err := listPages[StateVersionOutput](c, path, nil)
+	if err == ErrNotFound {
+		return outputs, ErrStateVersionNotFound
+	}
+	return outputs, err
+}
+
+// GetStateVersionOutput gets a specific state version output, including its
+// value when it is sensitive
+// Requires 1 request:
+// - /api/v2/state-version-outputs/:stateVersionOutputID
+func (c *Client) GetStateVersionOutput(stateVersionOutputID string) (StateVersionOutput, error) {
+	path := fmt.Sprintf("/api/v2/state-version-outputs/%s", stateVersionOutputID)
+
+	type wrapper struct {
+		Data StateVersionOutput `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return StateVersionOutput{}, ErrStateVersionOutputNotFound
+		}
+		return StateVersionOutput{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// GetCurrentOutputs gets the outputs of the current state version of a
+// workspace, by name, without the values of sensitive outputs
+// Requires 1+P requests, where P is the number of pages:
+// - GetWorkspace (1)
+// - /api/v2/workspaces/:workspaceID/current-state-version-outputs
+func (c *Client) GetCurrentOutputs(organization, workspace string) (map[string]StateVersionOutput, error) {
+	workspaceData, err := c.GetWorkspace(organization, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/api/v2/workspaces/%s/current-state-version-outputs", workspaceData.ID)
+	outputs, err := listPages[StateVersionOutput](c, path, nil)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, ErrStateVersionNotFound
+		}
+		return nil, err
+	}
+
+	byName := make(map[string]StateVersionOutput, len(outputs))
+	for _, o := range outputs {
+		byName[o.Attributes.Name] = o
+	}
+	return byName, nil
+}
+
+// Decode decodes the value of the output into dest, like json.Unmarshal.
+// It returns ErrSensitiveOutput when the value of a sensitive output was
+// withheld by the API.
+func (o StateVersionOutput) Decode(dest interface{}) error {
+	value := bytes.TrimSpace(o.Attributes.Value)
+	if o.Attributes.Sensitive && (len(value) == 0 || bytes.Equal(value, []byte("null"))) {
+		return ErrSensitiveOutput
+	}
+	if len(value) == 0 {
+		value = []byte("null")
+	}
+	return json.Unmarshal(value, dest)
+}
+
+// DecodeOutput decodes the value of an output into a T, e.g.
+// DecodeOutput[[]string](o) for a list of strings, or DecodeOutput[any](o)
+// for the generic JSON representation of the value
+func DecodeOutput[T any](o StateVersionOutput) (T, error) {
+	var v T
+	err := o.Decode(&v)
+	return v, err
+}
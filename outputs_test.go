@@ -0,0 +1,39 @@
+package tfe
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeOutput(t *testing.T) {
+	var outputs []StateVersionOutput
+	err := json.Unmarshal([]byte(`[
+		{"id": "wsout-1", "type": "state-version-outputs", "attributes": {"name": "zones", "sensitive": false, "type": "array", "value": ["a", "b"]}},
+		{"id": "wsout-2", "type": "state-version-outputs", "attributes": {"name": "port", "sensitive": false, "type": "number", "value": 5432}},
+		{"id": "wsout-3", "type": "state-version-outputs", "attributes": {"name": "tags", "sensitive": false, "type": "object", "value": {"team": "infra"}}},
+		{"id": "wsout-4", "type": "state-version-outputs", "attributes": {"name": "password", "sensitive": true, "type": "string", "value": null}}
+	]`), &outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := DecodeOutput[[]string](outputs[0])
+	if err != nil || !reflect.DeepEqual(zones, []string{"a", "b"}) {
+		t.Errorf("zones: got %v, %v", zones, err)
+	}
+
+	port, err := DecodeOutput[int](outputs[1])
+	if err != nil || port != 5432 {
+		t.Errorf("port: got %v, %v", port, err)
+	}
+
+	tags, err := DecodeOutput[any](outputs[2])
+	if err != nil || !reflect.DeepEqual(tags, map[string]interface{}{"team": "infra"}) {
+		t.Errorf("tags: got %v, %v", tags, err)
+	}
+
+	if _, err := DecodeOutput[string](outputs[3]); err != ErrSensitiveOutput {
+		t.Errorf("password: expected ErrSensitiveOutput, got %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrPolicyNotFound is returned when a policy does not exist or is not
+// visible to the token
+var ErrPolicyNotFound = errors.New("Policy not found")
+
+// ErrPolicySetNotFound is returned when a policy set does not exist or is
+// not visible to the token
+var ErrPolicySetNotFound = errors.New("Policy set not found")
+
+// ErrInvalidPolicy is returned by CreatePolicy when the options would be
+// rejected by the API, e.g. an OPA policy without a query
+var ErrInvalidPolicy = errors.New("Invalid policy")
+
+// policyPayload is the writable subset of a policy
+type policyPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name             *string `json:"name,omitempty"`
+		Description      *string `json:"description,omitempty"`
+		Kind             *string `json:"kind,omitempty"`
+		Query            *string `json:"query,omitempty"`
+		EnforcementLevel *string `json:"enforcement-level,omitempty"`
+	} `json:"attributes"`
+	Relationships Relationships `json:"relationships,omitempty"`
+}
+
+// policySetPayload is the writable subset of a policy set
+type policySetPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name              *string  `json:"name,omitempty"`
+		Description       *string  `json:"description,omitempty"`
+		Kind              *string  `json:"kind,omitempty"`
+		Global            *bool    `json:"global,omitempty"`
+		Overridable       *bool    `json:"overridable,omitempty"`
+		PoliciesPath      *string  `json:"policies-path,omitempty"`
+		PolicyToolVersion *string  `json:"policy-tool-version,omitempty"`
+		VCSRepo           *VCSRepo `json:"vcs-repo,omitempty"`
+	} `json:"attributes"`
+	Relationships Relationships `json:"relationships,omitempty"`
+}
+
+// ListPolicies lists all policies of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/policies
+func (c *Client) ListPolicies(organization string) ([]Policy, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/policies", organization)
+	policies, err := listPages[Policy](c, path, nil)
+	if err == ErrNotFound {
+		return policies, ErrOrganizationNotFound
+	}
+	return policies, err
+}
+
+// GetPolicy gets a specific policy
+// Requires 1 request:
+// - /api/v2/policies/:policyID
+func (c *Client) GetPolicy(policyID string) (Policy, error) {
+	path := fmt.Sprintf("/api/v2/policies/%s", policyID)
+
+	type wrapper struct {
+		Data Policy `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Policy{}, ErrPolicyNotFound
+		}
+		return Policy{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreatePolicy creates a new policy, optionally adding it to policy sets.
+// Its code is uploaded separately with UploadPolicy. It fails with
+// ErrInvalidPolicy, without any request, when EnforcementLevel is empty or
+// an OPA policy has no Query.
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/policies
+func (c *Client) CreatePolicy(organization string, options CreatePolicyOptions) (Policy, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/policies", organization)
+
+	kind := options.Kind
+	if kind == "" {
+		kind = PolicyKindSentinel
+	}
+	if options.EnforcementLevel == "" {
+		return Policy{}, fmt.Errorf("%w: %s: an enforcement level is required", ErrInvalidPolicy, options.Name)
+	}
+	if kind == PolicyKindOPA && options.Query == "" {
+		return Policy{}, fmt.Errorf("%w: %s: OPA policies require a query", ErrInvalidPolicy, options.Name)
+	}
+
+	payload := policyPayload{Type: "policies"}
+	payload.Attributes.Name = &options.Name
+	payload.Attributes.Description = &options.Description
+	payload.Attributes.Kind = &kind
+	payload.Attributes.EnforcementLevel = &options.EnforcementLevel
+	if options.Query != "" {
+		payload.Attributes.Query = &options.Query
+	}
+	if len(options.PolicySetIDs) > 0 {
+		payload.Relationships = Relationships{"policy-sets": toMany("policy-sets", options.PolicySetIDs)}
+	}
+
+	return c.writePolicy(http.MethodPost, path, payload)
+}
+
+// UpdatePolicy updates the settings of a policy
+// Requires 1 request:
+// - PATCH /api/v2/policies/:policyID
+func (c *Client) UpdatePolicy(policyID string, options UpdatePolicyOptions) (Policy, error) {
+	path := fmt.Sprintf("/api/v2/policies/%s", policyID)
+
+	payload := policyPayload{Type: "policies"}
+	payload.Attributes.Description = options.Description
+	payload.Attributes.Query = options.Query
+	payload.Attributes.EnforcementLevel = options.EnforcementLevel
+
+	return c.writePolicy(http.MethodPatch, path, payload)
+}
+
+func (c *Client) writePolicy(method, path string, payload policyPayload) (Policy, error) {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return Policy{}, err
+	}
+
+	type wrapperResp struct {
+		Data Policy `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Policy{}, ErrPolicyNotFound
+		}
+		return Policy{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// UploadPolicy uploads the code of a policy, replacing the previous one
+// Requires 1 request:
+// - PUT /api/v2/policies/:policyID/upload
+func (c *Client) UploadPolicy(policyID string, content []byte) error {
+	path := fmt.Sprintf("/api/v2/policies/%s/upload", policyID)
+
+	if err := c.upload(c.BaseURL+path, true, content); err != nil {
+		if err == ErrNotFound {
+			return ErrPolicyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeletePolicy deletes a policy, removing it from its policy sets
+// Requires 1 request:
+// - DELETE /api/v2/policies/:policyID
+func (c *Client) DeletePolicy(policyID string) error {
+	path := fmt.Sprintf("/api/v2/policies/%s", policyID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrPolicyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListPolicySets lists all policy sets of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/policy-sets
+func (c *Client) ListPolicySets(organization string) ([]PolicySet, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/policy-sets", organization)
+	sets, err := listPages[PolicySet](c, path, nil)
+	if err == ErrNotFound {
+		return sets, ErrOrganizationNotFound
+	}
+	return sets, err
+}
+
+// GetPolicySet gets a specific policy set
+// Requires 1 request:
+// - /api/v2/policy-sets/:policySetID
+func (c *Client) GetPolicySet(policySetID string) (PolicySet, error) {
+	path := fmt.Sprintf("/api/v2/policy-sets/%s", policySetID)
+
+	type wrapper struct {
+		Data PolicySet `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return PolicySet{}, ErrPolicySetNotFound
+		}
+		return PolicySet{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreatePolicySet creates a new policy set, optionally applied to
+// workspaces and projects
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/policy-sets
+func (c *Client) CreatePolicySet(organization string, options CreatePolicySetOptions) (PolicySet, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/policy-sets", organization)
+
+	kind := options.Kind
+	if kind == "" {
+		kind = PolicyKindSentinel
+	}
+
+	payload := policySetPayload{Type: "policy-sets"}
+	payload.Attributes.Name = &options.Name
+	payload.Attributes.Description = &options.Description
+	payload.Attributes.Kind = &kind
+	payload.Attributes.Global = &options.Global
+	payload.Attributes.VCSRepo = options.VCSRepo
+	if kind == PolicyKindOPA {
+		payload.Attributes.Overridable = &options.Overridable
+	}
+	if options.PoliciesPath != "" {
+		payload.Attributes.PoliciesPath = &options.PoliciesPath
+	}
+	if options.PolicyToolVersion != "" {
+		payload.Attributes.PolicyToolVersion = &options.PolicyToolVersion
+	}
+	payload.Relationships = Relationships{}
+	if len(options.PolicyIDs) > 0 {
+		payload.Relationships["policies"] = toMany("policies", options.PolicyIDs)
+	}
+	if len(options.WorkspaceIDs) > 0 {
+		payload.Relationships["workspaces"] = toMany("workspaces", options.WorkspaceIDs)
+	}
+	if len(options.ProjectIDs) > 0 {
+		payload.Relationships["projects"] = toMany("projects", options.ProjectIDs)
+	}
+
+	return c.writePolicySet(http.MethodPost, path, payload)
+}
+
+// UpdatePolicySet updates the settings of a policy set
+// Requires 1 request:
+// - PATCH /api/v2/policy-sets/:policySetID
+func (c *Client) UpdatePolicySet(policySetID string, options UpdatePolicySetOptions) (PolicySet, error) {
+	path := fmt.Sprintf("/api/v2/policy-sets/%s", policySetID)
+
+	payload := policySetPayload{Type: "policy-sets"}
+	payload.Attributes.Name = options.Name
+	payload.Attributes.Description = options.Description
+	payload.Attributes.Global = options.Global
+	payload.Attributes.Overridable = options.Overridable
+	payload.Attributes.PoliciesPath = options.PoliciesPath
+	payload.Attributes.PolicyToolVersion = options.PolicyToolVersion
+	payload.Attributes.VCSRepo = options.VCSRepo
+
+	return c.writePolicySet(http.MethodPatch, path, payload)
+}
+
+func (c *Client) writePolicySet(method, path string, payload policySetPayload) (PolicySet, error) {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return PolicySet{}, err
+	}
+
+	type wrapperResp struct {
+		Data PolicySet `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return PolicySet{}, ErrPolicySetNotFound
+		}
+		return PolicySet{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeletePolicySet deletes a policy set. Its policies are kept.
+// Requires 1 request:
+// - DELETE /api/v2/policy-sets/:policySetID
+func (c *Client) DeletePolicySet(policySetID string) error {
+	path := fmt.Sprintf("/api/v2/policy-sets/%s", policySetID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrPolicySetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AddPoliciesToPolicySet adds policies to a policy set
+// Requires 1 request:
+// - POST /api/v2/policy-sets/:policySetID/relationships/policies
+func (c *Client) AddPoliciesToPolicySet(policySetID string, policyIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodPost, policySetID, "policies", policyIDs)
+}
+
+// RemovePoliciesFromPolicySet removes policies from a policy set
+// Requires 1 request:
+// - DELETE /api/v2/policy-sets/:policySetID/relationships/policies
+func (c *Client) RemovePoliciesFromPolicySet(policySetID string, policyIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodDelete, policySetID, "policies", policyIDs)
+}
+
+// ApplyPolicySetToWorkspaces applies a policy set to workspaces
+// Requires 1 request:
+// - POST /api/v2/policy-sets/:policySetID/relationships/workspaces
+func (c *Client) ApplyPolicySetToWorkspaces(policySetID string, workspaceIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodPost, policySetID, "workspaces", workspaceIDs)
+}
+
+// RemovePolicySetFromWorkspaces removes a policy set from workspaces
+// Requires 1 request:
+// - DELETE /api/v2/policy-sets/:policySetID/relationships/workspaces
+func (c *Client) RemovePolicySetFromWorkspaces(policySetID string, workspaceIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodDelete, policySetID, "workspaces", workspaceIDs)
+}
+
+// ApplyPolicySetToProjects applies a policy set to projects, and so to all
+// of their workspaces
+// Requires 1 request:
+// - POST /api/v2/policy-sets/:policySetID/relationships/projects
+func (c *Client) ApplyPolicySetToProjects(policySetID string, projectIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodPost, policySetID, "projects", projectIDs)
+}
+
+// RemovePolicySetFromProjects removes a policy set from projects
+// Requires 1 request:
+// - DELETE /api/v2/policy-sets/:policySetID/relationships/projects
+func (c *Client) RemovePolicySetFromProjects(policySetID string, projectIDs ...string) error {
+	return c.updatePolicySetRelationship(http.MethodDelete, policySetID, "projects", projectIDs)
+}
+
+func (c *Client) updatePolicySetRelationship(method, policySetID, typ string, ids []string) error {
+	path := fmt.Sprintf("/api/v2/policy-sets/%s/relationships/%s", policySetID, typ)
+
+	b, err := json.Marshal(toMany(typ, ids))
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrPolicySetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// upload PUTs raw content, authenticating for API endpoints but not for
+// presigned upload URLs. Failures are retried like API requests.
+func (c *Client) upload(rawURL string, authenticate bool, content []byte) error {
+	var status int
+	return withRetries(
+		func() error {
+			status = 0
+			req, err := http.NewRequest(http.MethodPut, rawURL, bytes.NewReader(content))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", "application/octet-stream")
+			if authenticate {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.AtlasToken.Reveal()))
+			}
+
+			resp, err := c.client.Do(req)
+			if err != nil {
+				if authenticate {
+					return err
+				}
+				return redactURLError(err)
+			}
+			resp.Body.Close()
+			status = resp.StatusCode
+
+			switch {
+			case resp.StatusCode == 401:
+				return ErrUnauthorized
+			case resp.StatusCode == 404:
+				return ErrNotFound
+			case resp.StatusCode > 299:
+				return ErrBadStatus
+			}
+			return nil
+		},
+		func(e error) bool {
+			if e == ErrBadStatus {
+				return retryableStatus(status)
+			}
+			if e, ok := e.(net.Error); ok && e.Timeout() {
+				return true
+			}
+			return false
+		},
+		10,
+	)
+}
@@ -0,0 +1,264 @@
+package tfe
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUploadPolicySetVersion(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sentinel.hcl":             `policy "tags" { enforcement_level = "advisory" }`,
+		"tags.sentinel":            `main = rule { true }`,
+		"modules/helpers.sentinel": `func ok() { return true }`,
+		".git/config":              `[core]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/policy-sets/polset-1/versions":
+			fmt.Fprintf(w, `{"data": {"id": "polsetver-1", "attributes": {"status": "pending"}, "links": {"upload": "http://%s/upload/secret"}}}`, r.Host)
+		case r.Method == http.MethodPut && r.URL.Path == "/upload/secret":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("token sent to the upload link")
+			}
+			got := untar(t, r.Body)
+			want := map[string]string{
+				"modules/":                 "",
+				"modules/helpers.sentinel": files["modules/helpers.sentinel"],
+				"sentinel.hcl":             files["sentinel.hcl"],
+				"tags.sentinel":            files["tags.sentinel"],
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got archive %v", got)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	version, err := New("token", server.URL).UploadPolicySetVersion("polset-1", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.ID != "polsetver-1" {
+		t.Errorf("got %+v", version)
+	}
+}
+
+func untar(t *testing.T, r io.Reader) map[string]string {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(tr)
+		files[h.Name] = string(b)
+	}
+}
+
+func TestCreatePolicy(t *testing.T) {
+	var got map[string]interface{}
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v2/organizations/org/policies":
+			json.Unmarshal(b, &got)
+			fmt.Fprint(w, `{"data": {"id": "pol-1", "attributes": {"name": "deny-public", "kind": "opa"}}}`)
+		case "/api/v2/policies/pol-1/upload":
+			if r.Method != http.MethodPut || r.Header.Get("Content-Type") != "application/octet-stream" {
+				t.Errorf("unexpected upload %s %s", r.Method, r.Header.Get("Content-Type"))
+			}
+			uploaded = string(b)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	c := New("token", server.URL)
+	policy, err := c.CreatePolicy("org", CreatePolicyOptions{
+		Name:             "deny-public",
+		Kind:             PolicyKindOPA,
+		Query:            "data.terraform.deny",
+		EnforcementLevel: EnforcementMandatory,
+		PolicySetIDs:     []string{"polset-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadPolicy(policy.ID, []byte("package terraform")); err != nil {
+		t.Fatal(err)
+	}
+
+	data := got["data"].(map[string]interface{})
+	want := map[string]interface{}{
+		"name":              "deny-public",
+		"description":       "",
+		"kind":              "opa",
+		"query":             "data.terraform.deny",
+		"enforcement-level": "mandatory",
+	}
+	if !reflect.DeepEqual(data["attributes"], want) {
+		t.Errorf("got attributes %v", data["attributes"])
+	}
+	sets := data["relationships"].(map[string]interface{})["policy-sets"].(map[string]interface{})["data"]
+	if !reflect.DeepEqual(sets, []interface{}{map[string]interface{}{"id": "polset-1", "type": "policy-sets"}}) {
+		t.Errorf("got policy sets %v", sets)
+	}
+	if uploaded != "package terraform" {
+		t.Errorf("got upload %q", uploaded)
+	}
+}
+
+func TestCreatePolicyValidation(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusCreated, `{"data": {"id": "pol-1"}}`)
+	c := New("token", server.URL)
+
+	for _, options := range []CreatePolicyOptions{
+		{Name: "no-level"},
+		{Name: "no-query", Kind: PolicyKindOPA, EnforcementLevel: EnforcementMandatory},
+	} {
+		if _, err := c.CreatePolicy("org", options); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: got %v, want ErrInvalidPolicy", options.Name, err)
+		}
+	}
+	if len(*requests) != 0 {
+		t.Errorf("invalid policies sent: %+v", *requests)
+	}
+}
+
+func TestCreatePolicySet(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusCreated, `{"data": {"id": "polset-1"}}`)
+	c := New("token", server.URL)
+
+	if _, err := c.CreatePolicySet("org", CreatePolicySetOptions{
+		Name:         "baseline",
+		Overridable:  true,
+		PolicyIDs:    []string{"pol-1", "pol-2"},
+		WorkspaceIDs: []string{"ws-1"},
+		ProjectIDs:   []string{"prj-1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreatePolicySet("org", CreatePolicySetOptions{
+		Name:         "opa",
+		Kind:         PolicyKindOPA,
+		PoliciesPath: "policies/opa",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overridable only applies to OPA policy sets, and is sent for them
+	// even when false
+	want := []recordedRequest{
+		{
+			Method: http.MethodPost,
+			Path:   "/api/v2/organizations/org/policy-sets",
+			Body: map[string]interface{}{"data": map[string]interface{}{
+				"type": "policy-sets",
+				"attributes": map[string]interface{}{
+					"name":        "baseline",
+					"description": "",
+					"kind":        "sentinel",
+					"global":      false,
+				},
+				"relationships": map[string]interface{}{
+					"policies":   toManyBody("policies", "pol-1", "pol-2"),
+					"workspaces": toManyBody("workspaces", "ws-1"),
+					"projects":   toManyBody("projects", "prj-1"),
+				},
+			}},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/api/v2/organizations/org/policy-sets",
+			Body: map[string]interface{}{"data": map[string]interface{}{
+				"type": "policy-sets",
+				"attributes": map[string]interface{}{
+					"name":          "opa",
+					"description":   "",
+					"kind":          "opa",
+					"global":        false,
+					"overridable":   false,
+					"policies-path": "policies/opa",
+				},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %+v\nwant %+v", *requests, want)
+	}
+}
+
+func TestPolicySetRelationships(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusNoContent, "")
+	c := New("token", server.URL)
+
+	for _, call := range []func() error{
+		func() error { return c.AddPoliciesToPolicySet("polset-1", "pol-1") },
+		func() error { return c.RemovePoliciesFromPolicySet("polset-1", "pol-1") },
+		func() error { return c.ApplyPolicySetToWorkspaces("polset-1", "ws-1", "ws-2") },
+		func() error { return c.RemovePolicySetFromWorkspaces("polset-1", "ws-1") },
+		func() error { return c.ApplyPolicySetToProjects("polset-1", "prj-1") },
+		func() error { return c.RemovePolicySetFromProjects("polset-1", "prj-1") },
+	} {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []recordedRequest{
+		{http.MethodPost, "/api/v2/policy-sets/polset-1/relationships/policies", toManyBody("policies", "pol-1")},
+		{http.MethodDelete, "/api/v2/policy-sets/polset-1/relationships/policies", toManyBody("policies", "pol-1")},
+		{http.MethodPost, "/api/v2/policy-sets/polset-1/relationships/workspaces", toManyBody("workspaces", "ws-1", "ws-2")},
+		{http.MethodDelete, "/api/v2/policy-sets/polset-1/relationships/workspaces", toManyBody("workspaces", "ws-1")},
+		{http.MethodPost, "/api/v2/policy-sets/polset-1/relationships/projects", toManyBody("projects", "prj-1")},
+		{http.MethodDelete, "/api/v2/policy-sets/polset-1/relationships/projects", toManyBody("projects", "prj-1")},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %+v\nwant %+v", *requests, want)
+	}
+
+	missing, _ := recordingServer(t, http.StatusNotFound, "")
+	if err := New("token", missing.URL).AddPoliciesToPolicySet("polset-2", "pol-1"); err != ErrPolicySetNotFound {
+		t.Errorf("expected ErrPolicySetNotFound, got %v", err)
+	}
+}
+
+// toManyBody is the decoded JSON of a to-many relationship
+func toManyBody(typ string, ids ...string) map[string]interface{} {
+	data := []interface{}{}
+	for _, id := range ids {
+		data = append(data, map[string]interface{}{"id": id, "type": typ})
+	}
+	return map[string]interface{}{"data": data}
+}
@@ -0,0 +1,144 @@
+package tfe
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrPolicySetVersionNotFound is returned when a policy set version does not
+// exist or is not visible to the token
+var ErrPolicySetVersionNotFound = errors.New("Policy set version not found")
+
+// CreatePolicySetVersion creates a new version of a policy set, whose
+// policies are then uploaded to its upload link
+// Requires 1 request:
+// - POST /api/v2/policy-sets/:policySetID/versions
+func (c *Client) CreatePolicySetVersion(policySetID string) (PolicySetVersion, error) {
+	path := fmt.Sprintf("/api/v2/policy-sets/%s/versions", policySetID)
+
+	type wrapper struct {
+		Data PolicySetVersion `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(http.MethodPost, path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return PolicySetVersion{}, ErrPolicySetNotFound
+		}
+		return PolicySetVersion{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// GetPolicySetVersion gets a specific policy set version, e.g. to check its
+// status after an upload
+// Requires 1 request:
+// - /api/v2/policy-set-versions/:policySetVersionID
+func (c *Client) GetPolicySetVersion(policySetVersionID string) (PolicySetVersion, error) {
+	path := fmt.Sprintf("/api/v2/policy-set-versions/%s", policySetVersionID)
+
+	type wrapper struct {
+		Data PolicySetVersion `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return PolicySetVersion{}, ErrPolicySetVersionNotFound
+		}
+		return PolicySetVersion{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// UploadPolicySetVersion packs a local directory of policies, with their
+// sentinel.hcl or OPA configuration, as a tar.gz archive and uploads it as
+// a new version of a policy set. The version is processed asynchronously,
+// poll GetPolicySetVersion until its status is ready or errored.
+// Requires 2 requests:
+// - POST /api/v2/policy-sets/:policySetID/versions
+// - PUT to the upload link of the version
+func (c *Client) UploadPolicySetVersion(policySetID, dir string) (PolicySetVersion, error) {
+	var archive bytes.Buffer
+	if err := packPolicyDirectory(dir, &archive); err != nil {
+		return PolicySetVersion{}, err
+	}
+
+	version, err := c.CreatePolicySetVersion(policySetID)
+	if err != nil {
+		return PolicySetVersion{}, err
+	}
+
+	upload, ok := version.Links["upload"]
+	if !ok {
+		return version, fmt.Errorf("policy set version %s has no upload link", version.ID)
+	}
+	if err := c.upload(string(upload), false, archive.Bytes()); err != nil {
+		return version, err
+	}
+	return version, nil
+}
+
+// packPolicyDirectory writes the regular files of dir to w as a tar.gz
+// archive, with paths relative to dir. Hidden directories such as .git are
+// left out.
+func packPolicyDirectory(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && filepath.Base(rel)[0] == '.' {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
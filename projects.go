@@ -0,0 +1,43 @@
+package tfe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrProjectNotFound is returned when a project does not exist or is not
+// visible to the token
+var ErrProjectNotFound = errors.New("Project not found")
+
+// ListProjects lists all projects of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/projects
+func (c *Client) ListProjects(organization string) ([]Project, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/projects", organization)
+	projects, err := listPages[Project](c, path, nil)
+	if err == ErrNotFound {
+		return projects, ErrOrganizationNotFound
+	}
+	return projects, err
+}
+
+// GetProject gets a specific project
+// Requires 1 request:
+// - /api/v2/projects/:projectID
+func (c *Client) GetProject(projectID string) (Project, error) {
+	path := fmt.Sprintf("/api/v2/projects/%s", projectID)
+
+	type wrapper struct {
+		Data Project `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Project{}, ErrProjectNotFound
+		}
+		return Project{}, err
+	}
+
+	return resp.Data, nil
+}
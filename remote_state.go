@@ -0,0 +1,172 @@
+package tfe
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOutputNotFound is returned by RemoteState when the state has no output
+// with the requested name
+var ErrOutputNotFound = errors.New("Output not found")
+
+// RemoteState reads the outputs of a workspace from Go, like the
+// terraform_remote_state data source does from Terraform.
+// Outputs are cached for the TTL. Once it expires, the current state version
+// is checked again and outputs are only fetched when it changed.
+// Values of sensitive outputs are fetched on first use.
+// A RemoteState is safe for concurrent use. Requests are made without
+// holding its lock, so concurrent callers may refresh at the same time.
+type RemoteState struct {
+	client       *Client
+	organization string
+	workspace    string
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu             sync.Mutex
+	workspaceID    string
+	checked        time.Time
+	stateVersionID string
+	serial         int
+	// outputs is replaced, never modified, so that it can be read without
+	// holding mu
+	outputs map[string]StateVersionOutput
+}
+
+// NewRemoteState creates a reader for the outputs of a workspace, caching
+// them for ttl
+func NewRemoteState(client *Client, organization, workspace string, ttl time.Duration) *RemoteState {
+	return &RemoteState{
+		client:       client,
+		organization: organization,
+		workspace:    workspace,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+// Output decodes the value of the named output into dest, like
+// json.Unmarshal
+// Requires 0 requests when cached, otherwise at most 2+P, see Refresh, plus
+// 1 for sensitive outputs on first use:
+// - GetStateVersionOutput (1)
+func (r *RemoteState) Output(name string, dest interface{}) error {
+	outputs, stateVersionID, err := r.current(false)
+	if err != nil {
+		return err
+	}
+
+	o, ok := outputs[name]
+	if !ok {
+		return ErrOutputNotFound
+	}
+
+	err = o.Decode(dest)
+	if err != ErrSensitiveOutput {
+		return err
+	}
+
+	o, err = r.client.GetStateVersionOutput(o.ID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.stateVersionID == stateVersionID {
+		revealed := make(map[string]StateVersionOutput, len(r.outputs))
+		for k, v := range r.outputs {
+			revealed[k] = v
+		}
+		revealed[name] = o
+		r.outputs = revealed
+	}
+	r.mu.Unlock()
+
+	return o.Decode(dest)
+}
+
+// Outputs returns all outputs of the workspace, by name. Values of sensitive
+// outputs are null unless they were already read through Output.
+func (r *RemoteState) Outputs() (map[string]StateVersionOutput, error) {
+	current, _, err := r.current(false)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]StateVersionOutput, len(current))
+	for k, v := range current {
+		outputs[k] = v
+	}
+	return outputs, nil
+}
+
+// Serial returns the serial of the state the cached outputs come from
+func (r *RemoteState) Serial() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.serial
+}
+
+// Refresh checks the current state version right away, regardless of the
+// TTL, and fetches its outputs if it changed
+// Requires 1 request, plus 1 on first use and P when the state version
+// changed, where P is the number of pages of outputs:
+// - GetWorkspace (1)
+// - /api/v2/workspaces/:workspaceID/current-state-version
+// - ListStateVersionOutputs (P)
+func (r *RemoteState) Refresh() error {
+	_, _, err := r.current(true)
+	return err
+}
+
+// current returns the cached outputs and the ID of their state version,
+// refreshing them first when force is set or the TTL expired
+func (r *RemoteState) current(force bool) (map[string]StateVersionOutput, string, error) {
+	r.mu.Lock()
+	if !force && r.outputs != nil && r.now().Sub(r.checked) < r.ttl {
+		defer r.mu.Unlock()
+		return r.outputs, r.stateVersionID, nil
+	}
+	workspaceID, stateVersionID, cached := r.workspaceID, r.stateVersionID, r.outputs != nil
+	r.mu.Unlock()
+
+	if workspaceID == "" {
+		ws, err := r.client.GetWorkspace(r.organization, r.workspace)
+		if err != nil {
+			return nil, "", err
+		}
+		workspaceID = ws.ID
+	}
+
+	sv, err := r.client.currentStateVersion(workspaceID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Serials are only comparable within a lineage, a state version with
+	// another ID may hold other outputs at the same serial
+	var outputs map[string]StateVersionOutput
+	if !cached || sv.ID != stateVersionID {
+		list, err := r.client.ListStateVersionOutputs(sv.ID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		outputs = make(map[string]StateVersionOutput, len(list))
+		for _, o := range list {
+			outputs[o.Attributes.Name] = o
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workspaceID = workspaceID
+	if outputs != nil {
+		r.outputs = outputs
+		r.stateVersionID = sv.ID
+		r.serial = sv.Attributes.Serial
+	}
+	r.checked = r.now()
+	return r.outputs, r.stateVersionID, nil
+}
@@ -0,0 +1,164 @@
+package tfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRemoteState(t *testing.T) {
+	var serial, outputLists, reveals int32
+	serial = 1
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/org/workspaces/network", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		s := atomic.LoadInt32(&serial)
+		fmt.Fprintf(w, `{"data": {"id": "sv-%d", "attributes": {"serial": %d}}}`, s, s)
+	})
+	mux.HandleFunc("/api/v2/state-versions/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&outputLists, 1)
+		fmt.Fprintf(w, `{"data": [
+			{"id": "wsout-1", "attributes": {"name": "vpc_id", "value": %q}},
+			{"id": "wsout-2", "attributes": {"name": "password", "sensitive": true, "value": null}}
+		]}`, r.URL.Path)
+	})
+	mux.HandleFunc("/api/v2/state-version-outputs/wsout-2", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reveals, 1)
+		fmt.Fprint(w, `{"data": {"id": "wsout-2", "attributes": {"name": "password", "sensitive": true, "value": "hunter2"}}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Now()
+	rs := NewRemoteState(New("token", server.URL), "org", "network", time.Minute)
+	rs.now = func() time.Time { return now }
+
+	var vpc string
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	if vpc != "/api/v2/state-versions/sv-1/outputs" {
+		t.Errorf("got vpc_id %q", vpc)
+	}
+
+	var password string
+	for i := 0; i < 2; i++ {
+		if err := rs.Output("password", &password); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if password != "hunter2" || reveals != 1 {
+		t.Errorf("got password %q after %d reveals", password, reveals)
+	}
+
+	if err := rs.Output("missing", &vpc); err != ErrOutputNotFound {
+		t.Errorf("expected ErrOutputNotFound, got %v", err)
+	}
+
+	// Expired TTL with an unchanged serial keeps the cached outputs
+	now = now.Add(2 * time.Minute)
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	if outputLists != 1 {
+		t.Errorf("outputs listed %d times, want 1", outputLists)
+	}
+
+	// A new serial refetches the outputs once the TTL expires
+	atomic.StoreInt32(&serial, 2)
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	if outputLists != 1 {
+		t.Errorf("outputs refetched before the TTL expired")
+	}
+	now = now.Add(2 * time.Minute)
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	if vpc != "/api/v2/state-versions/sv-2/outputs" || rs.Serial() != 2 {
+		t.Errorf("got vpc_id %q at serial %d", vpc, rs.Serial())
+	}
+}
+
+func TestRemoteStateNewLineage(t *testing.T) {
+	var stateVersion int32 = 1
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/org/workspaces/network", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		// The state is replaced with another lineage at the same serial
+		fmt.Fprintf(w, `{"data": {"id": "sv-%d", "attributes": {"serial": 1}}}`, atomic.LoadInt32(&stateVersion))
+	})
+	mux.HandleFunc("/api/v2/state-versions/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": [{"id": "wsout-1", "attributes": {"name": "vpc_id", "value": %q}}]}`, r.URL.Path)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	rs := NewRemoteState(New("token", server.URL), "org", "network", time.Minute)
+
+	var vpc string
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&stateVersion, 2)
+	if err := rs.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Output("vpc_id", &vpc); err != nil {
+		t.Fatal(err)
+	}
+	if vpc != "/api/v2/state-versions/sv-2/outputs" {
+		t.Errorf("got vpc_id %q, outputs of the new state version not fetched", vpc)
+	}
+}
+
+func TestRemoteStateUnlockedRequests(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/org/workspaces/network", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"id": "sv-1", "attributes": {"serial": 3}}}`)
+	})
+	mux.HandleFunc("/api/v2/state-versions/sv-1/outputs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	rs := NewRemoteState(New("token", server.URL), "org", "network", time.Minute)
+	done := make(chan error)
+	go func() { done <- rs.Refresh() }()
+
+	<-entered
+	serial := make(chan int)
+	go func() { serial <- rs.Serial() }()
+	select {
+	case <-serial:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("Serial blocked while a refresh was in flight")
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if rs.Serial() != 3 {
+		t.Errorf("got serial %d", rs.Serial())
+	}
+}
@@ -0,0 +1,129 @@
+package tfe
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Secret is a string that must not end up in logs. It formats as [REDACTED]
+// with every fmt verb, including %#v, and when logged through log/slog, but
+// is encoded as-is to JSON so it can be sent to Terraform Enterprise.
+// Use Reveal to get the actual value.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer. Empty secrets are formatted as an empty
+// string, so that unset values can be told apart.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// Format implements fmt.Formatter
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		io.WriteString(f, s.GoString())
+	case verb == 'q':
+		io.WriteString(f, strconv.Quote(s.String()))
+	default:
+		io.WriteString(f, s.String())
+	}
+}
+
+// LogValue implements slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Format implements fmt.Formatter, redacting the value of sensitive
+// variables. Values of other variables are formatted as-is.
+func (a VariableAttributes) Format(f fmt.State, verb rune) {
+	type plain VariableAttributes
+	if a.Sensitive {
+		a.Value = Secret(a.Value).String()
+	}
+	formatRedacted(f, verb, plain(a), "VariableAttributes")
+}
+
+// LogValue implements slog.LogValuer, redacting the value of sensitive
+// variables
+func (a VariableAttributes) LogValue() slog.Value {
+	return slog.StringValue(fmt.Sprintf("%+v", a))
+}
+
+// Format implements fmt.Formatter, redacting the value of sensitive
+// variables
+func (o CreateVariableOptions) Format(f fmt.State, verb rune) {
+	type plain CreateVariableOptions
+	if o.Sensitive {
+		o.Value = Secret(o.Value).String()
+	}
+	formatRedacted(f, verb, plain(o), "CreateVariableOptions")
+}
+
+// LogValue implements slog.LogValuer, redacting the value of sensitive
+// variables
+func (o CreateVariableOptions) LogValue() slog.Value {
+	return slog.StringValue(fmt.Sprintf("%+v", o))
+}
+
+// Format implements fmt.Formatter, redacting the value of sensitive outputs.
+// Values and types are formatted as JSON text rather than bytes. Use Decode
+// or DecodeOutput on the output to read the value.
+func (a StateVersionOutputAttributes) Format(f fmt.State, verb rune) {
+	type plain struct {
+		Name         string
+		Sensitive    bool
+		Type         string
+		Value        string
+		DetailedType string
+	}
+	p := plain{a.Name, a.Sensitive, a.Type, string(a.Value), string(a.DetailedType)}
+	if a.Sensitive {
+		p.Value = Secret(p.Value).String()
+	}
+	formatRedacted(f, verb, p, "StateVersionOutputAttributes")
+}
+
+// LogValue implements slog.LogValuer, redacting the value of sensitive
+// outputs
+func (a StateVersionOutputAttributes) LogValue() slog.Value {
+	return slog.StringValue(fmt.Sprintf("%+v", a))
+}
+
+// formatRedacted formats v, a redacted copy of a struct converted to a type
+// without methods, as if it were of the named type
+func formatRedacted(f fmt.State, verb rune, v interface{}, name string) {
+	s := fmt.Sprintf(fmt.FormatString(f, verb), v)
+	if verb == 'v' && f.Flag('#') {
+		s = strings.Replace(s, fmt.Sprintf("%T", v), "tfe."+name, 1)
+	}
+	io.WriteString(f, s)
+}
+
+// redactURLError removes the URL from errors returned by http.Client, for
+// requests to presigned URLs
+func redactURLError(err error) error {
+	if e, ok := err.(*url.Error); ok {
+		return &url.Error{Op: e.Op, URL: redacted, Err: e.Err}
+	}
+	return err
+}
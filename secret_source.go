@@ -0,0 +1,200 @@
+package tfe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Error Types
+var (
+	// ErrSecretNotFound is returned by secret sources when a reference
+	// points to nothing
+	ErrSecretNotFound = errors.New("Secret not found")
+
+	// ErrNoSecretResolver is returned when resolving a variable value
+	// without Client.Secrets set
+	ErrNoSecretResolver = errors.New("No secret resolver set")
+)
+
+// SecretSource resolves references to secret values
+type SecretSource interface {
+	// Resolve returns the secret designated by ref, the part of a reference
+	// following "scheme://"
+	Resolve(ctx context.Context, ref string) (Secret, error)
+}
+
+// SecretSourceFunc adapts a function to the SecretSource interface
+type SecretSourceFunc func(ctx context.Context, ref string) (Secret, error)
+
+// Resolve calls f(ctx, ref)
+func (f SecretSourceFunc) Resolve(ctx context.Context, ref string) (Secret, error) {
+	return f(ctx, ref)
+}
+
+// EnvSecretSource resolves references to environment variables, e.g.
+// env://AWS_SECRET_ACCESS_KEY
+type EnvSecretSource struct{}
+
+// Resolve returns the value of the environment variable named ref
+func (EnvSecretSource) Resolve(ctx context.Context, ref string) (Secret, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return Secret(v), nil
+}
+
+// FileSecretSource resolves references to files, e.g. file:///run/secrets/key
+// or file://key, which is read relative to Dir
+type FileSecretSource struct {
+	// Dir is the directory relative paths are resolved from, it defaults to
+	// the working directory
+	Dir string
+}
+
+// Resolve returns the contents of the file at ref, without trailing newlines
+func (s FileSecretSource) Resolve(ctx context.Context, ref string) (Secret, error) {
+	path := ref
+	if !filepath.IsAbs(path) && s.Dir != "" {
+		path = filepath.Join(s.Dir, path)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return Secret(strings.TrimRight(string(b), "\r\n")), nil
+}
+
+// ExecSecretSource resolves references to the output of a helper command,
+// e.g. exec://vault kv get -field=token secret/ci
+// The command is split on whitespace and run without a shell. References
+// execute arbitrary commands, so they must only come from trusted
+// configuration.
+type ExecSecretSource struct {
+	// Timeout bounds the run time of the command, it defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// Resolve runs the command in ref and returns its standard output, without
+// trailing newlines
+func (s ExecSecretSource) Resolve(ctx context.Context, ref string) (Secret, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", errors.New("empty exec secret reference")
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running %s: %v", args[0], err)
+	}
+	return Secret(strings.TrimRight(stdout.String(), "\r\n")), nil
+}
+
+// SecretResolver resolves URI-style secret references such as env://NAME,
+// file://path and exec://command in variable values, using the source
+// registered for their scheme
+type SecretResolver struct {
+	sources map[string]SecretSource
+}
+
+// NewSecretResolver creates a resolver with the env, file and exec sources
+// registered
+func NewSecretResolver() *SecretResolver {
+	r := &SecretResolver{sources: map[string]SecretSource{}}
+	r.Register("env", EnvSecretSource{})
+	r.Register("file", FileSecretSource{})
+	r.Register("exec", ExecSecretSource{})
+	return r
+}
+
+// Register sets the source used for references with the given scheme,
+// replacing any previously registered one. The zero SecretResolver has no
+// sources until some are registered.
+func (r *SecretResolver) Register(scheme string, source SecretSource) {
+	if r.sources == nil {
+		r.sources = map[string]SecretSource{}
+	}
+	r.sources[scheme] = source
+}
+
+// parse splits a reference into its source and ref, ok is false when value
+// is not a reference to a registered scheme. A nil resolver has no schemes.
+func (r *SecretResolver) parse(value string) (source SecretSource, scheme, ref string, ok bool) {
+	i := strings.Index(value, "://")
+	if r == nil || i <= 0 {
+		return nil, "", "", false
+	}
+	scheme = value[:i]
+	source, ok = r.sources[scheme]
+	return source, scheme, value[i+3:], ok
+}
+
+// IsReference reports whether value is a reference to a registered scheme
+func (r *SecretResolver) IsReference(value string) bool {
+	_, _, _, ok := r.parse(value)
+	return ok
+}
+
+// Resolve returns the secret designated by a reference. Values which are not
+// references are returned unchanged.
+func (r *SecretResolver) Resolve(ctx context.Context, value string) (Secret, error) {
+	source, scheme, ref, ok := r.parse(value)
+	if !ok {
+		return Secret(value), nil
+	}
+
+	s, err := source.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s secret reference %q: %w", scheme, ref, err)
+	}
+	return s, nil
+}
+
+// ResolveVariable resolves the value of a variable definition when it is a
+// reference, and marks the variable as sensitive
+func (r *SecretResolver) ResolveVariable(ctx context.Context, options CreateVariableOptions) (CreateVariableOptions, error) {
+	if !r.IsReference(options.Value) {
+		return options, nil
+	}
+
+	value, err := r.Resolve(ctx, options.Value)
+	if err != nil {
+		return options, err
+	}
+	options.Value = value.Reveal()
+	options.Sensitive = true
+	return options, nil
+}
+
+// resolveVariable resolves a secret reference in the value of a variable
+// definition with the client resolver, when asked to with ResolveSecrets
+func (c *Client) resolveVariable(options CreateVariableOptions) (CreateVariableOptions, error) {
+	if !options.ResolveSecrets {
+		return options, nil
+	}
+	if c.Secrets == nil {
+		return options, ErrNoSecretResolver
+	}
+	return c.Secrets.ResolveVariable(context.Background(), options)
+}
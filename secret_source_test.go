@@ -0,0 +1,125 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretResolver(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "key"), []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TFE_TEST_SECRET", "from-env")
+
+	r := NewSecretResolver()
+	r.Register("file", FileSecretSource{Dir: dir})
+	r.Register("static", SecretSourceFunc(func(ctx context.Context, ref string) (Secret, error) {
+		return Secret("static-" + ref), nil
+	}))
+
+	for value, want := range map[string]string{
+		"env://TFE_TEST_SECRET":               "from-env",
+		"file://key":                          "from-file",
+		"exec://echo from exec":               "from exec",
+		"static://x":                          "static-x",
+		"https://example.com":                 "https://example.com",
+		"plain value":                         "plain value",
+		"file://" + filepath.Join(dir, "key"): "from-file",
+	} {
+		got, err := r.Resolve(context.Background(), value)
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+			continue
+		}
+		if got.Reveal() != want {
+			t.Errorf("%s: got %q, want %q", value, got.Reveal(), want)
+		}
+	}
+
+	if _, err := r.Resolve(context.Background(), "env://TFE_TEST_MISSING"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestSecretResolverZeroValue(t *testing.T) {
+	var nilResolver *SecretResolver
+	if got, err := nilResolver.Resolve(context.Background(), "env://TFE_TEST_SECRET"); err != nil || got.Reveal() != "env://TFE_TEST_SECRET" {
+		t.Errorf("nil resolver: got %q, %v", got.Reveal(), err)
+	}
+
+	r := &SecretResolver{}
+	if got, err := r.Resolve(context.Background(), "static://x"); err != nil || got.Reveal() != "static://x" {
+		t.Errorf("empty resolver: got %q, %v", got.Reveal(), err)
+	}
+
+	r.Register("static", SecretSourceFunc(func(ctx context.Context, ref string) (Secret, error) {
+		return Secret("static-" + ref), nil
+	}))
+	if got, err := r.Resolve(context.Background(), "static://x"); err != nil || got.Reveal() != "static-x" {
+		t.Errorf("registered source: got %q, %v", got.Reveal(), err)
+	}
+}
+
+func TestSecretResolverVariable(t *testing.T) {
+	t.Setenv("TFE_TEST_SECRET", "from-env")
+	r := NewSecretResolver()
+
+	v, err := r.ResolveVariable(context.Background(), CreateVariableOptions{Key: "a", Value: "env://TFE_TEST_SECRET"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != "from-env" || !v.Sensitive {
+		t.Errorf("reference not resolved: %q sensitive=%v", v.Value, v.Sensitive)
+	}
+
+	v, err = r.ResolveVariable(context.Background(), CreateVariableOptions{Key: "b", Value: "literal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != "literal" || v.Sensitive {
+		t.Errorf("literal changed: %q sensitive=%v", v.Value, v.Sensitive)
+	}
+}
+
+func TestCreateVariableResolveSecrets(t *testing.T) {
+	t.Setenv("TFE_TEST_SECRET", "from-env")
+	server, requests := recordingServer(t, http.StatusCreated, `{"data": {"id": "var-1"}}`)
+	c := New("token", server.URL)
+	c.Secrets = NewSecretResolver()
+
+	sent := func() (interface{}, interface{}) {
+		last := (*requests)[len(*requests)-1]
+		attributes := last.Body["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+		return attributes["value"], attributes["sensitive"]
+	}
+
+	// References are only resolved when asked for, so that values from
+	// untrusted input never read local files or run commands
+	for _, value := range []string{"env://TFE_TEST_SECRET", "exec://echo leaked", "plain"} {
+		if _, err := c.CreateVariable("ws-1", CreateVariableOptions{Key: "k", Value: value, Category: CategoryEnv}); err != nil {
+			t.Fatal(err)
+		}
+		if got, sensitive := sent(); got != value || sensitive != false {
+			t.Errorf("%s: sent %v, sensitive=%v", value, got, sensitive)
+		}
+	}
+
+	for value, want := range map[string]string{"env://TFE_TEST_SECRET": "from-env", "plain": "plain"} {
+		if _, err := c.CreateVariable("ws-1", CreateVariableOptions{Key: "k", Value: value, Category: CategoryEnv, ResolveSecrets: true}); err != nil {
+			t.Fatal(err)
+		}
+		if got, sensitive := sent(); got != want || sensitive != (value != want) {
+			t.Errorf("%s: sent %v, sensitive=%v", value, got, sensitive)
+		}
+	}
+
+	c.Secrets = nil
+	if _, err := c.CreateVariable("ws-1", CreateVariableOptions{Key: "k", Value: "plain", Category: CategoryEnv, ResolveSecrets: true}); err != ErrNoSecretResolver {
+		t.Errorf("expected ErrNoSecretResolver, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretRedaction(t *testing.T) {
+	v := Variable{Attributes: VariableAttributes{Key: "token", Value: "hunter2", Sensitive: true}}
+	c := New("atlas-token", DefaultBaseURL)
+	sv := StateVersion{Attributes: StateVersionAttributes{HostedStateDownloadURL: "https://archivist/signed"}}
+
+	opts := CreateVariableOptions{Key: "token", Value: "hunter2", Sensitive: true}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x"} {
+		for _, value := range []interface{}{v, &v, c, sv, opts, Secret("hunter2")} {
+			out := fmt.Sprintf(format, value)
+			for _, s := range []string{"hunter2", "atlas-token", "archivist"} {
+				if strings.Contains(out, s) {
+					t.Errorf("%s leaked %q: %s", format, s, out)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("created", "variable", v.Attributes, "options", opts)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("slog leaked secret: %s", buf.String())
+	}
+
+	b, err := json.Marshal(v.Attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"value":"hunter2"`) {
+		t.Errorf("secret not encoded as-is: %s", b)
+	}
+
+	if got := Secret("").String(); got != "" {
+		t.Errorf("empty secret formatted as %q", got)
+	}
+	if got := Secret("hunter2").Reveal(); got != "hunter2" {
+		t.Errorf("Reveal returned %q", got)
+	}
+}
+
+func TestVariableValueFormatting(t *testing.T) {
+	plain := VariableAttributes{Key: "region", Value: "eu-west-1"}
+	if got := fmt.Sprintf("%+v", plain); !strings.Contains(got, "Value:eu-west-1") {
+		t.Errorf("non-sensitive value hidden: %s", got)
+	}
+	if got := fmt.Sprintf("%#v", plain); !strings.HasPrefix(got, "tfe.VariableAttributes{") {
+		t.Errorf("got %s", got)
+	}
+
+	sensitive := CreateVariableOptions{Key: "token", Value: "hunter2", Sensitive: true}
+	if got := fmt.Sprintf("%+v", sensitive); !strings.Contains(got, "Value:[REDACTED]") {
+		t.Errorf("got %s", got)
+	}
+	if sensitive.Value != "hunter2" {
+		t.Error("formatting changed the value")
+	}
+}
+
+func TestStateVersionOutputFormatting(t *testing.T) {
+	output := StateVersionOutput{ID: "wsout-1", Attributes: StateVersionOutputAttributes{
+		Name: "password", Sensitive: true, Type: "string", Value: json.RawMessage(`"hunter2"`),
+	}}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		if got := fmt.Sprintf(format, output); strings.Contains(got, "hunter2") {
+			t.Errorf("%s leaked the value: %s", format, got)
+		}
+	}
+	var log bytes.Buffer
+	slog.New(slog.NewTextHandler(&log, nil)).Info("output", "attributes", output.Attributes)
+	if strings.Contains(log.String(), "hunter2") {
+		t.Errorf("log leaked the value: %s", log.String())
+	}
+	if got := fmt.Sprintf("%#v", output.Attributes); !strings.HasPrefix(got, "tfe.StateVersionOutputAttributes{") {
+		t.Errorf("got %s", got)
+	}
+
+	var value string
+	if err := output.Decode(&value); err != nil || value != "hunter2" {
+		t.Errorf("decoded %q, %v", value, err)
+	}
+
+	output.Attributes.Sensitive = false
+	if got := fmt.Sprintf("%+v", output.Attributes); !strings.Contains(got, `Value:"hunter2"`) {
+		t.Errorf("non-sensitive value hidden: %s", got)
+	}
+}
@@ -0,0 +1,223 @@
+package tfe
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrSSHKeyNotFound is returned when an SSH key does not exist or is not
+// visible to the token
+var ErrSSHKeyNotFound = errors.New("SSH key not found")
+
+// sshKeyPayload is the writable subset of an SSH key
+type sshKeyPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name  *string `json:"name,omitempty"`
+		Value *Secret `json:"value,omitempty"`
+	} `json:"attributes"`
+}
+
+// ListSSHKeys lists the SSH keys of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/ssh-keys
+func (c *Client) ListSSHKeys(organization string) ([]SSHKey, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/ssh-keys", organization)
+	keys, err := listPages[SSHKey](c, path, nil)
+	if err == ErrNotFound {
+		return keys, ErrOrganizationNotFound
+	}
+	return keys, err
+}
+
+// GetSSHKey gets a specific SSH key
+// Requires 1 request:
+// - /api/v2/ssh-keys/:sshKeyID
+func (c *Client) GetSSHKey(sshKeyID string) (SSHKey, error) {
+	path := fmt.Sprintf("/api/v2/ssh-keys/%s", sshKeyID)
+
+	type wrapper struct {
+		Data SSHKey `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return SSHKey{}, ErrSSHKeyNotFound
+		}
+		return SSHKey{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateSSHKey uploads a PEM or OpenSSH encoded private SSH key
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/ssh-keys
+func (c *Client) CreateSSHKey(organization, name string, privateKey Secret) (SSHKey, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/ssh-keys", organization)
+
+	payload := sshKeyPayload{Type: "ssh-keys"}
+	payload.Attributes.Name = &name
+	payload.Attributes.Value = &privateKey
+
+	return c.writeSSHKey(http.MethodPost, path, payload)
+}
+
+// UpdateSSHKey renames an SSH key, or replaces its value
+// Requires 1 request:
+// - PATCH /api/v2/ssh-keys/:sshKeyID
+func (c *Client) UpdateSSHKey(sshKeyID string, options UpdateSSHKeyOptions) (SSHKey, error) {
+	path := fmt.Sprintf("/api/v2/ssh-keys/%s", sshKeyID)
+
+	payload := sshKeyPayload{Type: "ssh-keys"}
+	payload.Attributes.Name = options.Name
+	payload.Attributes.Value = options.Value
+
+	return c.writeSSHKey(http.MethodPatch, path, payload)
+}
+
+func (c *Client) writeSSHKey(method, path string, payload sshKeyPayload) (SSHKey, error) {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return SSHKey{}, err
+	}
+
+	type wrapperResp struct {
+		Data SSHKey `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return SSHKey{}, ErrSSHKeyNotFound
+		}
+		return SSHKey{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteSSHKey deletes an SSH key, unassigning it from its workspaces
+// Requires 1 request:
+// - DELETE /api/v2/ssh-keys/:sshKeyID
+func (c *Client) DeleteSSHKey(sshKeyID string) error {
+	path := fmt.Sprintf("/api/v2/ssh-keys/%s", sshKeyID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrSSHKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// UnassignWorkspaceSSHKey removes the SSH key of a workspace
+// Requires 1 request:
+// - PATCH /api/v2/workspaces/:workspaceID/relationships/ssh-key
+func (c *Client) UnassignWorkspaceSSHKey(workspaceID string) error {
+	return c.setWorkspaceSSHKey(workspaceID, nil)
+}
+
+func (c *Client) setWorkspaceSSHKey(workspaceID string, sshKeyID *string) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/relationships/ssh-key", workspaceID)
+
+	payload := AssignSSHKeyPayload{
+		Data: AssignSSHKeyData{
+			Type:       "workspaces",
+			Attributes: AssignSSHKeyAttributes{ID: sshKeyID},
+		},
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodPatch, path, bytes.NewBuffer(b), nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GenerateSSHKey generates an ed25519 key pair locally and uploads its
+// private key. The public key is returned in authorized_keys format, to be
+// added as a deploy key of the repositories to fetch.
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/ssh-keys
+func (c *Client) GenerateSSHKey(organization, name string) (SSHKey, string, error) {
+	privateKey, publicKey, err := GenerateED25519Key(name)
+	if err != nil {
+		return SSHKey{}, "", err
+	}
+
+	key, err := c.CreateSSHKey(organization, name, privateKey)
+	if err != nil {
+		return SSHKey{}, "", err
+	}
+	return key, publicKey, nil
+}
+
+// GenerateED25519Key generates an ed25519 key pair, returning the private key
+// in OpenSSH format, unencrypted, and the public key in authorized_keys
+// format, both with the given comment
+func GenerateED25519Key(comment string) (privateKey Secret, publicKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	pubWire := sshString(nil, []byte("ssh-ed25519"))
+	pubWire = sshString(pubWire, pub)
+
+	// The private section starts with twice the same random check value,
+	// and is padded to the cipher block size, 8 without encryption
+	check := make([]byte, 4)
+	if _, err := rand.Read(check); err != nil {
+		return "", "", err
+	}
+	private := append(append([]byte{}, check...), check...)
+	private = sshString(private, []byte("ssh-ed25519"))
+	private = sshString(private, pub)
+	private = sshString(private, priv)
+	private = sshString(private, []byte(comment))
+	for i := byte(1); len(private)%8 != 0; i++ {
+		private = append(private, i)
+	}
+
+	key := []byte("openssh-key-v1\x00")
+	key = sshString(key, []byte("none")) // cipher
+	key = sshString(key, []byte("none")) // kdf
+	key = sshString(key, nil)            // kdf options
+	key = binary.BigEndian.AppendUint32(key, 1)
+	key = sshString(key, pubWire)
+	key = sshString(key, private)
+
+	privateKey = Secret(pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: key}))
+	publicKey = "ssh-ed25519 " + base64.StdEncoding.EncodeToString(pubWire)
+	if comment != "" {
+		publicKey += " " + comment
+	}
+	return privateKey, publicKey, nil
+}
+
+// sshString appends s to b in the SSH wire format, prefixed by its length
+func sshString(b, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
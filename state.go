@@ -0,0 +1,380 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedStateVersion is returned when parsing a state file whose
+// format version is neither 3 nor 4
+var ErrUnsupportedStateVersion = errors.New("Unsupported state format version")
+
+// State is a parsed Terraform state file. States in format version 3 are
+// normalized to the version 4 shape when parsed, see ParseState.
+// Numbers in outputs and attributes are decoded as json.Number.
+type State struct {
+	Version          int                    `json:"version"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Serial           int64                  `json:"serial"`
+	Lineage          string                 `json:"lineage"`
+	Outputs          map[string]StateOutput `json:"outputs"`
+	Resources        []StateResource        `json:"resources"`
+}
+
+// StateOutput is a root module output. Type is the JSON encoding of the
+// output type, e.g. "string" or ["list","string"].
+type StateOutput struct {
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// StateResource is a resource, or data source when Mode is "data", and its
+// instances
+type StateResource struct {
+	Module    string          `json:"module,omitempty"`
+	Mode      string          `json:"mode"`
+	Type      string          `json:"type"`
+	Name      string          `json:"name"`
+	Each      string          `json:"each,omitempty"`
+	Provider  string          `json:"provider"`
+	Instances []StateInstance `json:"instances"`
+}
+
+// StateInstance is a single instance of a resource. IndexKey is a
+// json.Number for count instances, a string for for_each instances, and nil
+// otherwise.
+// States upgraded from format version 3 only have AttributesFlat, in the
+// flatmap format of Terraform 0.11.
+type StateInstance struct {
+	IndexKey            interface{}            `json:"index_key,omitempty"`
+	Status              string                 `json:"status,omitempty"`
+	Deposed             string                 `json:"deposed,omitempty"`
+	SchemaVersion       int                    `json:"schema_version"`
+	Attributes          map[string]interface{} `json:"attributes,omitempty"`
+	AttributesFlat      map[string]string      `json:"attributes_flat,omitempty"`
+	SensitiveAttributes json.RawMessage        `json:"sensitive_attributes,omitempty"`
+	Private             string                 `json:"private,omitempty"`
+	Dependencies        []string               `json:"dependencies,omitempty"`
+	CreateBeforeDestroy bool                   `json:"create_before_destroy,omitempty"`
+}
+
+// Address returns the address of the resource, e.g.
+// module.network.aws_subnet.private or data.aws_ami.base
+func (r StateResource) Address() string {
+	addr := r.Type + "." + r.Name
+	if r.Mode == "data" {
+		addr = "data." + addr
+	}
+	if r.Module != "" {
+		addr = r.Module + "." + addr
+	}
+	return addr
+}
+
+// InstanceAddress returns the address of an instance of the resource, e.g.
+// aws_instance.web[0] or aws_instance.web["blue"]
+func (r StateResource) InstanceAddress(i StateInstance) string {
+	switch k := i.IndexKey.(type) {
+	case json.Number:
+		return fmt.Sprintf("%s[%s]", r.Address(), k)
+	case string:
+		return fmt.Sprintf("%s[%q]", r.Address(), k)
+	case float64:
+		return fmt.Sprintf("%s[%d]", r.Address(), int(k))
+	}
+	return r.Address()
+}
+
+// ParseState parses a raw state file in format version 3 or 4. Version 3
+// states are normalized to the version 4 shape:
+// - only root module outputs are kept, with types implied from their values
+// - resources are grouped with their count instances
+// - instance attributes are kept in AttributesFlat
+func ParseState(raw []byte) (*State, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	switch header.Version {
+	case 3:
+		var v3 stateV3
+		if err := dec.Decode(&v3); err != nil {
+			return nil, err
+		}
+		return upgradeStateV3(v3)
+	case 4:
+		var s State
+		if err := dec.Decode(&s); err != nil {
+			return nil, err
+		}
+		if s.Outputs == nil {
+			s.Outputs = map[string]StateOutput{}
+		}
+		return &s, nil
+	}
+	return nil, ErrUnsupportedStateVersion
+}
+
+type stateV3 struct {
+	Version          int             `json:"version"`
+	TerraformVersion string          `json:"terraform_version"`
+	Serial           int64           `json:"serial"`
+	Lineage          string          `json:"lineage"`
+	Modules          []moduleStateV3 `json:"modules"`
+}
+
+type moduleStateV3 struct {
+	Path      []string                   `json:"path"`
+	Outputs   map[string]outputStateV3   `json:"outputs"`
+	Resources map[string]resourceStateV3 `json:"resources"`
+}
+
+type outputStateV3 struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      string          `json:"type"`
+	Value     json.RawMessage `json:"value"`
+}
+
+type resourceStateV3 struct {
+	Type      string             `json:"type"`
+	DependsOn []string           `json:"depends_on"`
+	Primary   *instanceStateV3   `json:"primary"`
+	Deposed   []*instanceStateV3 `json:"deposed"`
+	Provider  string             `json:"provider"`
+}
+
+type instanceStateV3 struct {
+	ID         string                 `json:"id"`
+	Attributes map[string]string      `json:"attributes"`
+	Meta       map[string]interface{} `json:"meta"`
+	Tainted    bool                   `json:"tainted"`
+}
+
+func upgradeStateV3(v3 stateV3) (*State, error) {
+	s := &State{
+		Version:          4,
+		TerraformVersion: v3.TerraformVersion,
+		Serial:           v3.Serial,
+		Lineage:          v3.Lineage,
+		Outputs:          map[string]StateOutput{},
+		Resources:        []StateResource{},
+	}
+
+	for _, m := range v3.Modules {
+		module := moduleAddressV3(m.Path)
+
+		if module == "" {
+			for name, o := range m.Outputs {
+				typ, err := impliedType(o.Value)
+				if err != nil {
+					return nil, fmt.Errorf("output %s: %v", name, err)
+				}
+				s.Outputs[name] = StateOutput{Value: o.Value, Type: typ, Sensitive: o.Sensitive}
+			}
+		}
+
+		resources := map[string]*StateResource{}
+		for key, rs := range m.Resources {
+			r, index, err := parseResourceKeyV3(key)
+			if err != nil {
+				return nil, err
+			}
+			r.Module = module
+
+			addr := r.Address()
+			existing, ok := resources[addr]
+			if !ok {
+				r.Provider = providerV3(module, rs.Provider, r.Type)
+				existing = &r
+				resources[addr] = existing
+			}
+			if index >= 0 {
+				existing.Each = "list"
+			}
+
+			deps := make([]string, 0, len(rs.DependsOn))
+			for _, d := range rs.DependsOn {
+				d = strings.TrimSuffix(d, ".*")
+				if module != "" {
+					d = module + "." + d
+				}
+				deps = append(deps, d)
+			}
+
+			if rs.Primary != nil {
+				existing.Instances = append(existing.Instances, instanceV3(rs.Primary, index, deps))
+			}
+			for i, d := range rs.Deposed {
+				inst := instanceV3(d, index, deps)
+				inst.Deposed = fmt.Sprintf("%08x", i+1)
+				existing.Instances = append(existing.Instances, inst)
+			}
+		}
+
+		for _, r := range resources {
+			if r.Each == "list" {
+				for i := range r.Instances {
+					if r.Instances[i].IndexKey == nil {
+						r.Instances[i].IndexKey = json.Number("0")
+					}
+				}
+			}
+			sort.SliceStable(r.Instances, func(i, j int) bool {
+				a, _ := strconv.Atoi(fmt.Sprint(r.Instances[i].IndexKey))
+				b, _ := strconv.Atoi(fmt.Sprint(r.Instances[j].IndexKey))
+				return a < b
+			})
+			s.Resources = append(s.Resources, *r)
+		}
+	}
+
+	sort.Slice(s.Resources, func(i, j int) bool {
+		return s.Resources[i].Address() < s.Resources[j].Address()
+	})
+	return s, nil
+}
+
+// moduleAddressV3 converts a version 3 module path, e.g. ["root","a","b"],
+// to a module address, e.g. module.a.module.b
+func moduleAddressV3(path []string) string {
+	parts := []string{}
+	for _, p := range path {
+		if p == "root" && len(parts) == 0 {
+			continue
+		}
+		parts = append(parts, "module."+p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseResourceKeyV3 parses version 3 resource keys such as
+// aws_instance.web, aws_instance.web.2 and data.aws_ami.base. The index is
+// -1 for resources without count.
+func parseResourceKeyV3(key string) (StateResource, int, error) {
+	r := StateResource{Mode: "managed"}
+	parts := strings.Split(key, ".")
+	if parts[0] == "data" {
+		r.Mode = "data"
+		parts = parts[1:]
+	}
+
+	index := -1
+	switch len(parts) {
+	case 3:
+		i, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return r, 0, fmt.Errorf("invalid resource key %q", key)
+		}
+		index = i
+	case 2:
+	default:
+		return r, 0, fmt.Errorf("invalid resource key %q", key)
+	}
+
+	r.Type = parts[0]
+	r.Name = parts[1]
+	return r, index, nil
+}
+
+// providerV3 converts a version 3 provider reference, e.g. provider.aws.west
+// to a version 4 provider address, e.g.
+// provider["registry.terraform.io/-/aws"].west, with the legacy provider
+// namespace used by terraform when upgrading states
+func providerV3(module, provider, resourceType string) string {
+	if provider == "" {
+		name := resourceType
+		if i := strings.IndexByte(name, '_'); i > 0 {
+			name = name[:i]
+		}
+		provider = "provider." + name
+	}
+
+	if i := strings.Index(provider, "provider."); i > 0 {
+		module = strings.TrimSuffix(provider[:i], ".")
+		provider = provider[i:]
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(provider, "provider."), ".", 2)
+	addr := fmt.Sprintf("provider[%q]", "registry.terraform.io/-/"+parts[0])
+	if len(parts) == 2 {
+		addr += "." + parts[1]
+	}
+	if module != "" {
+		addr = module + "." + addr
+	}
+	return addr
+}
+
+func instanceV3(is *instanceStateV3, index int, deps []string) StateInstance {
+	inst := StateInstance{
+		AttributesFlat: is.Attributes,
+		Dependencies:   deps,
+	}
+	if inst.AttributesFlat == nil {
+		inst.AttributesFlat = map[string]string{}
+	}
+	if _, ok := inst.AttributesFlat["id"]; !ok && is.ID != "" {
+		inst.AttributesFlat["id"] = is.ID
+	}
+	if index >= 0 {
+		inst.IndexKey = json.Number(strconv.Itoa(index))
+	}
+	if is.Tainted {
+		inst.Status = "tainted"
+	}
+	if v, ok := is.Meta["schema_version"]; ok {
+		inst.SchemaVersion, _ = strconv.Atoi(fmt.Sprint(v))
+	}
+	return inst
+}
+
+// impliedType returns the JSON encoded terraform type of a JSON value
+func impliedType(value json.RawMessage) (json.RawMessage, error) {
+	if len(value) == 0 {
+		return json.RawMessage(`"dynamic"`), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(typeOf(v))
+}
+
+func typeOf(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		elems := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			elems = append(elems, typeOf(e))
+		}
+		return []interface{}{"tuple", elems}
+	case map[string]interface{}:
+		attrs := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			attrs[k] = typeOf(e)
+		}
+		return []interface{}{"object", attrs}
+	}
+	return "dynamic"
+}
@@ -0,0 +1,166 @@
+package tfe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateCache is an on-disk cache of raw state files, keyed by state version
+// ID. State versions are immutable, so cached entries never go stale.
+// Entries are encrypted at rest with AES-GCM, and the least recently used
+// entries are evicted once the cache grows over its maximum size.
+// Set Client.StateCache to use it for DownloadState and DownloadLatestState,
+// which still get the state version first so that access is checked.
+// A StateCache is safe for concurrent use, but not for sharing a directory
+// between processes.
+type StateCache struct {
+	dir      string
+	aead     cipher.AEAD
+	maxBytes int64
+
+	mu sync.Mutex
+}
+
+const stateCacheExt = ".state"
+
+// NewStateCache creates a cache storing state files in dir, encrypted with
+// key, which must be 16, 24 or 32 bytes long to select AES-128, AES-192 or
+// AES-256. A maxBytes of zero means no size limit.
+func NewStateCache(dir string, key []byte, maxBytes int64) (*StateCache, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &StateCache{
+		dir:      dir,
+		aead:     aead,
+		maxBytes: maxBytes,
+	}, nil
+}
+
+// path returns the file of a state version, named after a hash of its ID so
+// that IDs never escape the cache directory
+func (c *StateCache) path(stateVersionID string) string {
+	sum := sha256.Sum256([]byte(stateVersionID))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+stateCacheExt)
+}
+
+// Get returns the cached state of a state version, ok being false on cache
+// misses. Entries which cannot be decrypted, e.g. after a key change, are
+// removed and reported as misses.
+func (c *StateCache) Get(stateVersionID string) (raw []byte, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(stateVersionID)
+	sealed, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	n := c.aead.NonceSize()
+	if len(sealed) >= n {
+		raw, err = c.aead.Open(nil, sealed[:n], sealed[n:], []byte(stateVersionID))
+	}
+	if len(sealed) < n || err != nil {
+		return nil, false, os.Remove(path)
+	}
+
+	// The modification time tracks use for LRU eviction
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return nil, false, err
+	}
+	return raw, true, nil
+}
+
+// Put stores the state of a state version, then evicts the least recently
+// used entries if the cache is over its maximum size
+func (c *StateCache) Put(stateVersionID string, raw []byte) error {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := c.aead.Seal(nonce, nonce, raw, []byte(stateVersionID))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tmp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(stateVersionID)); err != nil {
+		return err
+	}
+
+	return c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits in its
+// maximum size
+func (c *StateCache) evict() error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	files := []os.FileInfo{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), stateCacheExt) {
+			continue
+		}
+		files = append(files, e)
+		total += e.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= f.Size()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package tfe
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateCache(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 32)
+	state := bytes.Repeat([]byte(`{"secret": "hunter2"}`), 10)
+
+	cache, err := NewStateCache(dir, key, int64(2*len(state)+100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := cache.Get("sv-1"); ok || err != nil {
+		t.Fatalf("expected a miss, got %v, %v", ok, err)
+	}
+
+	for _, id := range []string{"sv-1", "sv-2"} {
+		if err := cache.Put(id, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw, ok, err := cache.Get("sv-1")
+	if err != nil || !ok || !bytes.Equal(raw, state) {
+		t.Fatalf("got %q, %v, %v", raw, ok, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, f := range files {
+		b, _ := ioutil.ReadFile(f)
+		if bytes.Contains(b, []byte("hunter2")) {
+			t.Errorf("%s is not encrypted", f)
+		}
+	}
+
+	// sv-2 is now the least recently used entry, and is evicted by sv-3
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.path("sv-2"), old, old)
+	if err := cache.Put("sv-3", state); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := cache.Get("sv-2"); ok {
+		t.Error("expected sv-2 to be evicted")
+	}
+	if _, ok, _ := cache.Get("sv-1"); !ok {
+		t.Error("expected sv-1 to be kept")
+	}
+
+	// Entries written with another key are misses
+	other, err := NewStateCache(dir, bytes.Repeat([]byte{2}, 32), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := other.Get("sv-1"); ok || err != nil {
+		t.Errorf("expected a miss with another key, got %v, %v", ok, err)
+	}
+}
+
+func TestDownloadStateCached(t *testing.T) {
+	var requests, downloads int
+	unauthorized := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/state" {
+			downloads++
+			fmt.Fprint(w, `{"version": 4}`)
+			return
+		}
+		if unauthorized {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"id": "sv-1", "attributes": {"hosted-state-download-url": "%s/state"}}}`, "http://"+r.Host)
+	}))
+	defer server.Close()
+
+	cache, err := NewStateCache(t.TempDir(), bytes.Repeat([]byte{1}, 16), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("token", server.URL)
+	c.StateCache = cache
+
+	for i := 0; i < 3; i++ {
+		raw, err := c.DownloadState("org", "ws", "sv-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != `{"version": 4}` {
+			t.Errorf("got %s", raw)
+		}
+	}
+	// The state version is still fetched each time, only the download is
+	// skipped
+	if requests != 4 || downloads != 1 {
+		t.Errorf("got %d requests and %d downloads, want 4 and 1", requests, downloads)
+	}
+
+	// A cached state is not returned without access to its state version
+	unauthorized = true
+	if _, err := c.DownloadState("org", "ws", "sv-1"); err != ErrUnauthorized {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
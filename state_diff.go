@@ -0,0 +1,316 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiffAction is the kind of change found between two states
+type DiffAction string
+
+// Diff actions
+const (
+	DiffAdded   DiffAction = "added"
+	DiffRemoved DiffAction = "removed"
+	DiffChanged DiffAction = "changed"
+)
+
+// StateDiff lists the differences between two states, sorted by address and
+// name
+type StateDiff struct {
+	Resources []ResourceDiff
+	Outputs   []OutputDiff
+}
+
+// ResourceDiff is a resource instance that was added, removed or changed.
+// Deposed instances have their deposed key appended to their address.
+type ResourceDiff struct {
+	Address string
+	Action  DiffAction
+	Changes []AttributeChange
+}
+
+// AttributeChange is a changed attribute of a resource instance. Path uses
+// the terraform syntax, e.g. tags.Name or ingress[0].cidr_blocks[1]. Before
+// and After are nil when the attribute did not exist, and for sensitive
+// attributes.
+type AttributeChange struct {
+	Path      string
+	Before    interface{}
+	After     interface{}
+	Sensitive bool
+}
+
+// OutputDiff is a root module output that was added, removed or changed.
+// Before and After are nil for sensitive outputs.
+type OutputDiff struct {
+	Name      string
+	Action    DiffAction
+	Before    json.RawMessage
+	After     json.RawMessage
+	Sensitive bool
+}
+
+// StateVersionDiff is the difference between two consecutive state versions
+type StateVersionDiff struct {
+	From StateVersion
+	To   StateVersion
+	Diff StateDiff
+}
+
+// Empty reports whether the states are identical
+func (d StateDiff) Empty() bool {
+	return len(d.Resources) == 0 && len(d.Outputs) == 0
+}
+
+// DiffStates compares two states, a being the older one. Either may be nil to
+// stand for an empty state.
+func DiffStates(a, b *State) StateDiff {
+	diff := StateDiff{}
+
+	before, after := stateInstances(a), stateInstances(b)
+	for _, addr := range unionKeys(before, after) {
+		ib, inBefore := before[addr]
+		ia, inAfter := after[addr]
+		switch {
+		case !inBefore:
+			diff.Resources = append(diff.Resources, ResourceDiff{Address: addr, Action: DiffAdded})
+		case !inAfter:
+			diff.Resources = append(diff.Resources, ResourceDiff{Address: addr, Action: DiffRemoved})
+		default:
+			if changes := diffInstances(ib, ia); len(changes) > 0 {
+				diff.Resources = append(diff.Resources, ResourceDiff{Address: addr, Action: DiffChanged, Changes: changes})
+			}
+		}
+	}
+
+	var outBefore, outAfter map[string]StateOutput
+	if a != nil {
+		outBefore = a.Outputs
+	}
+	if b != nil {
+		outAfter = b.Outputs
+	}
+	for _, name := range unionKeys(outBefore, outAfter) {
+		ob, inBefore := outBefore[name]
+		oa, inAfter := outAfter[name]
+
+		d := OutputDiff{
+			Name:      name,
+			Before:    ob.Value,
+			After:     oa.Value,
+			Sensitive: ob.Sensitive || oa.Sensitive,
+		}
+		switch {
+		case !inBefore:
+			d.Action = DiffAdded
+		case !inAfter:
+			d.Action = DiffRemoved
+		case !jsonEqual(ob.Value, oa.Value) || ob.Sensitive != oa.Sensitive:
+			d.Action = DiffChanged
+		default:
+			continue
+		}
+		if d.Sensitive {
+			d.Before, d.After = nil, nil
+		}
+		diff.Outputs = append(diff.Outputs, d)
+	}
+
+	return diff
+}
+
+// DiffStateVersions diffs the consecutive state versions of a workspace
+// created between since and until, a zero until meaning now. The first diff
+// is against the last state version created before since, if any.
+// Requires P+V requests, where P is the number of pages of state versions
+// up to the one before since, and V the number of state versions diffed:
+// - ListStateVersionsWithOptions (P)
+// - download from HostedStateDownloadURL (V)
+func (c *Client) DiffStateVersions(organization, workspace string, since, until time.Time) ([]StateVersionDiff, error) {
+	selected, err := c.ListStateVersionsWithOptions(organization, workspace, ListStateVersionsOptions{
+		Since:       since,
+		Until:       until,
+		OldestFirst: true,
+		IncludeBase: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []StateVersionDiff{}
+	var previous *State
+	for i, sv := range selected {
+		raw, err := c.downloadStateVersion(sv)
+		if err != nil {
+			return nil, err
+		}
+		state, err := ParseState(raw)
+		if err != nil {
+			return nil, fmt.Errorf("state version %s: %v", sv.ID, err)
+		}
+
+		if i > 0 {
+			diffs = append(diffs, StateVersionDiff{
+				From: selected[i-1],
+				To:   sv,
+				Diff: DiffStates(previous, state),
+			})
+		}
+		previous = state
+	}
+	return diffs, nil
+}
+
+// stateInstances indexes the resource instances of a state by address
+func stateInstances(s *State) map[string]StateInstance {
+	instances := map[string]StateInstance{}
+	if s == nil {
+		return instances
+	}
+	for _, r := range s.Resources {
+		for _, i := range r.Instances {
+			addr := r.InstanceAddress(i)
+			if i.Deposed != "" {
+				addr += " (deposed " + i.Deposed + ")"
+			}
+			instances[addr] = i
+		}
+	}
+	return instances
+}
+
+func diffInstances(before, after StateInstance) []AttributeChange {
+	b, a := flattenInstance(before), flattenInstance(after)
+	sensitive := append(sensitivePaths(before), sensitivePaths(after)...)
+
+	changes := []AttributeChange{}
+	for _, path := range unionKeys(b, a) {
+		vb, inBefore := b[path]
+		va, inAfter := a[path]
+		if inBefore && inAfter && reflect.DeepEqual(vb, va) {
+			continue
+		}
+
+		change := AttributeChange{Path: path, Before: vb, After: va}
+		for _, s := range sensitive {
+			if path == s || strings.HasPrefix(path, s+".") || strings.HasPrefix(path, s+"[") {
+				change = AttributeChange{Path: path, Sensitive: true}
+				break
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if before.Status != after.Status {
+		changes = append(changes, AttributeChange{Path: "(status)", Before: before.Status, After: after.Status})
+	}
+	return changes
+}
+
+// flattenInstance returns the leaf attribute values of an instance by path
+func flattenInstance(i StateInstance) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for k, v := range i.AttributesFlat {
+		flat[k] = v
+	}
+	for k, v := range i.Attributes {
+		flattenValue(flat, attributeStep("", k), v)
+	}
+	return flat
+}
+
+func flattenValue(flat map[string]interface{}, path string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			flat[path] = v
+		}
+		for k, e := range v {
+			flattenValue(flat, attributeStep(path, k), e)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			flat[path] = v
+		}
+		for i, e := range v {
+			flattenValue(flat, fmt.Sprintf("%s[%d]", path, i), e)
+		}
+	case json.Number:
+		flat[path] = v.String()
+	default:
+		flat[path] = v
+	}
+}
+
+func attributeStep(path, key string) string {
+	if !isHCLIdent(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sensitivePaths converts the sensitive_attributes of an instance to
+// attribute paths
+func sensitivePaths(i StateInstance) []string {
+	var steps [][]struct {
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}
+	if len(i.SensitiveAttributes) == 0 || json.Unmarshal(i.SensitiveAttributes, &steps) != nil {
+		return nil
+	}
+
+	paths := []string{}
+	for _, s := range steps {
+		path := ""
+		for _, step := range s {
+			switch v := step.Value.(type) {
+			case string:
+				if step.Type == "get_attr" {
+					path = attributeStep(path, v)
+				} else {
+					path = fmt.Sprintf("%s[%q]", path, v)
+				}
+			case float64:
+				path = fmt.Sprintf("%s[%d]", path, int(v))
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// unionKeys returns the sorted keys of both maps
+func unionKeys[A, B any](a map[string]A, b map[string]B) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package tfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffStates(t *testing.T) {
+	a, err := ParseState([]byte(`{
+		"version": 4, "serial": 1, "lineage": "l",
+		"outputs": {
+			"kept": {"value": "x", "type": "string"},
+			"changed": {"value": ["a"], "type": ["list", "string"]},
+			"removed": {"value": 1, "type": "number"},
+			"password": {"value": "old", "type": "string", "sensitive": true}
+		},
+		"resources": [
+			{"mode": "managed", "type": "aws_instance", "name": "web", "each": "list", "instances": [
+				{"index_key": 0, "attributes": {"id": "i-0", "tags": {"Name": "web"}, "sg": ["a", "b"], "password": "p1"},
+				 "sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]},
+				{"index_key": 1, "attributes": {"id": "i-1"}}
+			]},
+			{"mode": "managed", "type": "aws_s3_bucket", "name": "logs", "instances": [{"attributes": {"id": "logs"}}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ParseState([]byte(`{
+		"version": 4, "serial": 2, "lineage": "l",
+		"outputs": {
+			"kept": {"value": "x", "type": "string"},
+			"changed": {"value": ["a", "b"], "type": ["list", "string"]},
+			"added": {"value": true, "type": "bool"},
+			"password": {"value": "new", "type": "string", "sensitive": true}
+		},
+		"resources": [
+			{"mode": "managed", "type": "aws_instance", "name": "web", "each": "list", "instances": [
+				{"index_key": 0, "attributes": {"id": "i-0", "tags": {"Name": "web-1", "env": "prod"}, "sg": ["a"], "password": "p2"},
+				 "sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]},
+				{"index_key": 1, "attributes": {"id": "i-1"}}
+			]},
+			{"mode": "data", "type": "aws_ami", "name": "base", "instances": [{"attributes": {"id": "ami-1"}}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := DiffStates(a, b)
+
+	wantResources := []ResourceDiff{
+		{Address: "aws_instance.web[0]", Action: DiffChanged, Changes: []AttributeChange{
+			{Path: "password", Sensitive: true},
+			{Path: "sg[1]", Before: "b"},
+			{Path: "tags.Name", Before: "web", After: "web-1"},
+			{Path: "tags.env", After: "prod"},
+		}},
+		{Address: "aws_s3_bucket.logs", Action: DiffRemoved},
+		{Address: "data.aws_ami.base", Action: DiffAdded},
+	}
+	if !reflect.DeepEqual(diff.Resources, wantResources) {
+		t.Errorf("got resources %+v\nwant %+v", diff.Resources, wantResources)
+	}
+
+	got := map[string]DiffAction{}
+	for _, o := range diff.Outputs {
+		got[o.Name] = o.Action
+		if o.Name == "password" && (o.Before != nil || o.After != nil) {
+			t.Errorf("sensitive output values leaked in diff")
+		}
+	}
+	wantOutputs := map[string]DiffAction{
+		"added":    DiffAdded,
+		"changed":  DiffChanged,
+		"password": DiffChanged,
+		"removed":  DiffRemoved,
+	}
+	if !reflect.DeepEqual(got, wantOutputs) {
+		t.Errorf("got outputs %v", got)
+	}
+
+	if !DiffStates(a, a).Empty() {
+		t.Error("expected identical states to have an empty diff")
+	}
+	if d := DiffStates(nil, a); len(d.Resources) != 3 || len(d.Outputs) != 4 {
+		t.Errorf("unexpected diff against empty state: %+v", d)
+	}
+}
+
+func TestDiffStateVersions(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	downloads := []string{}
+	listings := 0
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/state/") {
+			n := strings.TrimPrefix(r.URL.Path, "/state/")
+			downloads = append(downloads, n)
+			fmt.Fprintf(w, `{"version": 4, "outputs": {"n": {"value": %s, "type": "number"}}}`, n)
+			return
+		}
+
+		// Newest first, like the API
+		listings++
+		versions := []string{}
+		for n := 4; n >= 1; n-- {
+			versions = append(versions, fmt.Sprintf(`{"id": "sv-%d", "attributes": {"created-at": %q, "hosted-state-download-url": "%s/state/%d"}}`,
+				n, day.AddDate(0, 0, n).Format(time.RFC3339), server.URL, n))
+		}
+		fmt.Fprintf(w, `{"data": [%s], "meta": {"pagination": {"current-page": 1, "total-pages": 1}}}`, strings.Join(versions, ","))
+	}))
+	defer server.Close()
+
+	c := New("token", server.URL)
+	diffs, err := c.DiffStateVersions("org", "ws", day.AddDate(0, 0, 2), day.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, d := range diffs {
+		got = append(got, d.From.ID+"->"+d.To.ID)
+		if len(d.Diff.Outputs) != 1 || d.Diff.Outputs[0].Action != DiffChanged {
+			t.Errorf("%s->%s: got outputs %+v", d.From.ID, d.To.ID, d.Diff.Outputs)
+		}
+	}
+	if want := []string{"sv-1->sv-2", "sv-2->sv-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got diffs %v, want %v", got, want)
+	}
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(downloads, want) {
+		t.Errorf("downloaded states %v, want %v", downloads, want)
+	}
+	if listings != 1 {
+		t.Errorf("listed state versions %d times, want 1", listings)
+	}
+}
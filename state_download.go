@@ -0,0 +1,173 @@
+package tfe
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Error Types
+var (
+	ErrStateTooLarge    = errors.New("State is larger than the maximum state size")
+	ErrChecksumMismatch = errors.New("State checksum does not match")
+)
+
+// errInterruptedDownload wraps errors reading a download, which can be
+// resumed
+type errInterruptedDownload struct {
+	err error
+}
+
+func (e errInterruptedDownload) Error() string {
+	return fmt.Sprintf("download interrupted: %v", e.err)
+}
+
+// DownloadStateTo streams the raw state file of a state version to w.
+// Interrupted downloads are retried, resuming with an HTTP Range request
+// from the last byte written. The MD5 checksum reported by the API, or by
+// the Content-MD5 header, is verified once the download completes, and
+// states larger than Client.MaxStateSize are refused with ErrStateTooLarge.
+// Requires 1 request, plus 1 per retry:
+// - download from HostedStateDownloadURL
+func (c *Client) DownloadStateTo(ctx context.Context, sv StateVersion, w io.Writer) error {
+	d := &stateDownload{
+		client:   c,
+		url:      sv.Attributes.HostedStateDownloadURL.Reveal(),
+		w:        w,
+		hash:     md5.New(),
+		checksum: strings.ToLower(sv.Attributes.MD5),
+	}
+
+	err := withRetries(
+		func() error {
+			return d.attempt(ctx)
+		},
+		func(e error) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if _, ok := e.(errInterruptedDownload); ok {
+				return true
+			}
+			if e == ErrBadStatus {
+				return retryableStatus(d.status)
+			}
+			if e, ok := e.(net.Error); ok && e.Timeout() {
+				// Retry timeouts
+				return true
+			}
+			return false
+		},
+		10,
+	)
+	if err != nil {
+		return err
+	}
+
+	if d.checksum != "" && d.checksum != hex.EncodeToString(d.hash.Sum(nil)) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// stateDownload tracks the progress of a download across attempts
+type stateDownload struct {
+	client   *Client
+	url      string
+	w        io.Writer
+	hash     hash.Hash
+	checksum string
+	written  int64
+	status   int
+}
+
+func (d *stateDownload) attempt(ctx context.Context) error {
+	req, err := http.NewRequest("GET", d.url, nil)
+	if err != nil {
+		return redactURLError(err)
+	}
+	req = req.WithContext(ctx)
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+	}
+
+	d.status = 0
+	resp, err := d.client.client.Do(req)
+	if err != nil {
+		return redactURLError(err)
+	}
+	defer resp.Body.Close()
+	d.status = resp.StatusCode
+
+	remaining := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && d.written > 0:
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != d.written {
+			return fmt.Errorf("unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the Range header, skip what was already written
+		if d.written > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, d.written); err != nil {
+				return errInterruptedDownload{err}
+			}
+			if remaining >= 0 {
+				remaining -= d.written
+			}
+		}
+	default:
+		return ErrBadStatus
+	}
+
+	if d.checksum == "" {
+		if sum, err := base64.StdEncoding.DecodeString(resp.Header.Get("Content-MD5")); err == nil && len(sum) == md5.Size {
+			d.checksum = hex.EncodeToString(sum)
+		}
+	}
+
+	var body io.Reader = resp.Body
+	if max := d.client.MaxStateSize; max > 0 {
+		if remaining >= 0 && d.written+remaining > max {
+			return ErrStateTooLarge
+		}
+		body = io.LimitReader(body, max-d.written+1)
+	}
+
+	r := &errorTrackingReader{r: body}
+	n, err := io.Copy(io.MultiWriter(d.w, d.hash), r)
+	d.written += n
+	if err != nil {
+		if r.err != nil {
+			return errInterruptedDownload{r.err}
+		}
+		return err
+	}
+	if max := d.client.MaxStateSize; max > 0 && d.written > max {
+		return ErrStateTooLarge
+	}
+	return nil
+}
+
+// errorTrackingReader records read errors, to tell them apart from write
+// errors in io.Copy
+type errorTrackingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errorTrackingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+package tfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestDownloadStateTo(t *testing.T) {
+	state := bytes.Repeat([]byte(`{"version": 4}`), 1000)
+	sum := md5.Sum(state)
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if r.Header.Get("Range") == "" {
+			// Announce the full state but stop halfway through
+			w.Header().Set("Content-Length", strconv.Itoa(len(state)))
+			w.Write(state[:len(state)/2])
+			return
+		}
+
+		var start int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(state)-1, len(state)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(state[start:])
+	}))
+	defer server.Close()
+
+	c := New("token", server.URL)
+	sv := StateVersion{Attributes: StateVersionAttributes{
+		HostedStateDownloadURL: Secret(server.URL),
+		MD5:                    hex.EncodeToString(sum[:]),
+	}}
+
+	var buf bytes.Buffer
+	if err := c.DownloadStateTo(context.Background(), sv, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), state) {
+		t.Errorf("downloaded %d bytes, want %d", buf.Len(), len(state))
+	}
+	if want := fmt.Sprintf("bytes=%d-", len(state)/2); len(ranges) != 2 || ranges[1] != want {
+		t.Errorf("got ranges %q, want resume at %s", ranges, want)
+	}
+
+	sv.Attributes.MD5 = "00000000000000000000000000000000"
+	if err := c.DownloadStateTo(context.Background(), sv, &bytes.Buffer{}); err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+
+	c.MaxStateSize = 100
+	if err := c.DownloadStateTo(context.Background(), sv, &bytes.Buffer{}); err != ErrStateTooLarge {
+		t.Errorf("expected ErrStateTooLarge, got %v", err)
+	}
+}
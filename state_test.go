@@ -0,0 +1,151 @@
+package tfe
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testStateV4 = `{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "serial": 12,
+  "lineage": "5f3c0a7e",
+  "outputs": {
+    "vpc_id": {"value": "vpc-123", "type": "string"},
+    "db_password": {"value": "secret", "type": "string", "sensitive": true}
+  },
+  "resources": [
+    {
+      "module": "module.network",
+      "mode": "managed",
+      "type": "aws_subnet",
+      "name": "private",
+      "each": "list",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"index_key": 0, "schema_version": 1, "attributes": {"id": "subnet-1", "cidr_block": "10.0.0.0/24"}, "dependencies": ["module.network.aws_vpc.main"]},
+        {"index_key": 1, "schema_version": 1, "attributes": {"id": "subnet-2", "cidr_block": "10.0.1.0/24"}}
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "base",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"schema_version": 0, "attributes": {"id": "ami-1"}}]
+    }
+  ]
+}`
+
+const testStateV3 = `{
+  "version": 3,
+  "terraform_version": "0.11.14",
+  "serial": 7,
+  "lineage": "5f3c0a7e",
+  "modules": [
+    {
+      "path": ["root"],
+      "outputs": {
+        "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-123"},
+        "zones": {"sensitive": false, "type": "list", "value": ["a", "b"]}
+      },
+      "resources": {
+        "aws_instance.web.0": {"type": "aws_instance", "depends_on": ["aws_subnet.private.*"], "primary": {"id": "i-0", "attributes": {"id": "i-0", "tags.%": "1", "tags.Name": "web"}, "meta": {"schema_version": "1"}}, "provider": "provider.aws"},
+        "aws_instance.web.1": {"type": "aws_instance", "primary": {"id": "i-1", "attributes": {"id": "i-1"}, "tainted": true}, "provider": "provider.aws"},
+        "data.aws_ami.base": {"type": "aws_ami", "primary": {"id": "ami-1", "attributes": {"id": "ami-1"}}, "provider": "provider.aws.west"}
+      }
+    },
+    {
+      "path": ["root", "network"],
+      "outputs": {"ignored": {"type": "string", "value": "x"}},
+      "resources": {
+        "aws_vpc.main": {"type": "aws_vpc", "primary": {"id": "vpc-123", "attributes": {"id": "vpc-123"}}}
+      }
+    }
+  ]
+}`
+
+func TestParseStateV4(t *testing.T) {
+	s, err := ParseState([]byte(testStateV4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Serial != 12 || s.Lineage != "5f3c0a7e" || s.TerraformVersion != "1.5.7" {
+		t.Errorf("unexpected header: %+v", s)
+	}
+	if !s.Outputs["db_password"].Sensitive || string(s.Outputs["vpc_id"].Value) != `"vpc-123"` {
+		t.Errorf("unexpected outputs: %+v", s.Outputs)
+	}
+	if len(s.Resources) != 2 {
+		t.Fatalf("got %d resources", len(s.Resources))
+	}
+
+	r := s.Resources[0]
+	if got := r.InstanceAddress(r.Instances[1]); got != "module.network.aws_subnet.private[1]" {
+		t.Errorf("got address %s", got)
+	}
+	if got := s.Resources[1].Address(); got != "data.aws_ami.base" {
+		t.Errorf("got address %s", got)
+	}
+	if got := r.Instances[0].Attributes["cidr_block"]; got != "10.0.0.0/24" {
+		t.Errorf("got attribute %v", got)
+	}
+}
+
+func TestParseStateV3(t *testing.T) {
+	s, err := ParseState([]byte(testStateV3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Version != 4 || s.Serial != 7 || s.Lineage != "5f3c0a7e" {
+		t.Errorf("unexpected header: %+v", s)
+	}
+	if len(s.Outputs) != 2 {
+		t.Errorf("expected only root outputs, got %v", s.Outputs)
+	}
+	var typ interface{}
+	json.Unmarshal(s.Outputs["zones"].Type, &typ)
+	if want := []interface{}{"tuple", []interface{}{"string", "string"}}; !reflect.DeepEqual(typ, want) {
+		t.Errorf("got zones type %v", typ)
+	}
+
+	addrs := []string{}
+	for _, r := range s.Resources {
+		for _, i := range r.Instances {
+			addrs = append(addrs, r.InstanceAddress(i))
+		}
+	}
+	want := []string{"aws_instance.web[0]", "aws_instance.web[1]", "data.aws_ami.base", "module.network.aws_vpc.main"}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("got addresses %v", addrs)
+	}
+
+	web := s.Resources[0]
+	if web.Each != "list" || web.Provider != `provider["registry.terraform.io/-/aws"]` {
+		t.Errorf("unexpected resource: %+v", web)
+	}
+	if web.Instances[0].AttributesFlat["tags.Name"] != "web" || web.Instances[0].SchemaVersion != 1 {
+		t.Errorf("unexpected instance: %+v", web.Instances[0])
+	}
+	if !reflect.DeepEqual(web.Instances[0].Dependencies, []string{"aws_subnet.private"}) {
+		t.Errorf("got dependencies %v", web.Instances[0].Dependencies)
+	}
+	if web.Instances[1].Status != "tainted" {
+		t.Errorf("expected tainted instance")
+	}
+	if got := s.Resources[1].Provider; got != `provider["registry.terraform.io/-/aws"].west` {
+		t.Errorf("got provider %s", got)
+	}
+	if got := s.Resources[2].Provider; got != `module.network.provider["registry.terraform.io/-/aws"]` {
+		t.Errorf("got provider %s", got)
+	}
+}
+
+func TestParseStateUnsupported(t *testing.T) {
+	if _, err := ParseState([]byte(`{"version": 2}`)); err != ErrUnsupportedStateVersion {
+		t.Errorf("expected ErrUnsupportedStateVersion, got %v", err)
+	}
+}
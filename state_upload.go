@@ -0,0 +1,188 @@
+package tfe
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error Types
+var (
+	ErrWorkspaceLocked      = errors.New("Workspace is already locked")
+	ErrStateSerialConflict  = errors.New("State serial is not greater than the current state serial")
+	ErrStateLineageMismatch = errors.New("State lineage differs from the current state lineage")
+)
+
+// CreateStateVersionOptions controls the checks made by CreateStateVersion
+type CreateStateVersionOptions struct {
+	// Force skips the serial and lineage checks, both in the client and in
+	// Terraform Enterprise
+	Force bool
+
+	// LockReason is the reason shown while the workspace is locked for the
+	// upload
+	LockReason string
+}
+
+// LockWorkspace locks a workspace, returning ErrWorkspaceLocked if it is
+// already locked
+// Requires 1 request:
+// - POST /api/v2/workspaces/:workspaceID/actions/lock
+func (c *Client) LockWorkspace(workspaceID, reason string) (Workspace, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/actions/lock", workspaceID)
+
+	b, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		switch err {
+		case ErrNotFound:
+			return Workspace{}, ErrWorkspaceNotFound
+		case ErrConflict:
+			return Workspace{}, ErrWorkspaceLocked
+		}
+		return Workspace{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// UnlockWorkspace unlocks a workspace locked by the token owner
+// Requires 1 request:
+// - POST /api/v2/workspaces/:workspaceID/actions/unlock
+func (c *Client) UnlockWorkspace(workspaceID string) (Workspace, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/actions/unlock", workspaceID)
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(http.MethodPost, path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Workspace{}, ErrWorkspaceNotFound
+		}
+		return Workspace{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateStateVersion uploads a raw state file as the new current state of a
+// workspace. The workspace is locked during the upload, and unless forced,
+// the upload is refused with ErrStateSerialConflict when the serial of the
+// state is not greater than the current one, or ErrStateLineageMismatch when
+// its lineage differs.
+// Requires 4 requests, plus 1 to download the current state unless forced:
+// - LockWorkspace (1)
+// - /api/v2/workspaces/:workspaceID/current-state-version
+// - download from HostedStateDownloadURL
+// - POST /api/v2/workspaces/:workspaceID/state-versions
+// - UnlockWorkspace (1)
+func (c *Client) CreateStateVersion(workspaceID string, raw []byte, options CreateStateVersionOptions) (sv StateVersion, err error) {
+	var header struct {
+		Serial  *int64 `json:"serial"`
+		Lineage string `json:"lineage"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return StateVersion{}, err
+	}
+	if header.Serial == nil || header.Lineage == "" {
+		return StateVersion{}, errors.New("state has no serial or lineage")
+	}
+
+	if _, err := c.LockWorkspace(workspaceID, options.LockReason); err != nil {
+		return StateVersion{}, err
+	}
+	defer func() {
+		if _, unlockErr := c.UnlockWorkspace(workspaceID); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if !options.Force {
+		if err := c.checkStateVersion(workspaceID, *header.Serial, header.Lineage); err != nil {
+			return StateVersion{}, err
+		}
+	}
+
+	sum := md5.Sum(raw)
+	payload := struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Serial  int64  `json:"serial"`
+			MD5     string `json:"md5"`
+			Lineage string `json:"lineage"`
+			State   string `json:"state"`
+			Force   bool   `json:"force,omitempty"`
+		} `json:"attributes"`
+	}{Type: "state-versions"}
+	payload.Attributes.Serial = *header.Serial
+	payload.Attributes.MD5 = hex.EncodeToString(sum[:])
+	payload.Attributes.Lineage = header.Lineage
+	payload.Attributes.State = base64.StdEncoding.EncodeToString(raw)
+	payload.Attributes.Force = options.Force
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return StateVersion{}, err
+	}
+
+	type wrapperResp struct {
+		Data StateVersion `json:"data"`
+	}
+	var resp wrapperResp
+	path := fmt.Sprintf("/api/v2/workspaces/%s/state-versions", workspaceID)
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		return StateVersion{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// checkStateVersion makes sure a state with the given serial and lineage can
+// replace the current state of a workspace
+func (c *Client) checkStateVersion(workspaceID string, serial int64, lineage string) error {
+	current, err := c.currentStateVersion(workspaceID)
+	if err == ErrStateVersionNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if serial <= int64(current.Attributes.Serial) {
+		return ErrStateSerialConflict
+	}
+
+	currentRaw, err := c.downloadStateVersion(current)
+	if err != nil {
+		return err
+	}
+	var currentHeader struct {
+		Lineage string `json:"lineage"`
+	}
+	if err := json.Unmarshal(currentRaw, &currentHeader); err != nil {
+		return err
+	}
+	if currentHeader.Lineage != "" && currentHeader.Lineage != lineage {
+		return ErrStateLineageMismatch
+	}
+	return nil
+}
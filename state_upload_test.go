@@ -0,0 +1,124 @@
+package tfe
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateStateVersion(t *testing.T) {
+	var locked bool
+	var uploaded map[string]interface{}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/workspaces/ws-1/actions/lock", func(w http.ResponseWriter, r *http.Request) {
+		if locked {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		locked = true
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/actions/unlock", func(w http.ResponseWriter, r *http.Request) {
+		locked = false
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": {"id": "sv-1", "attributes": {"serial": 5, "hosted-state-download-url": "%s/state"}}}`, server.URL)
+	})
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version": 4, "serial": 5, "lineage": "abc"}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/state-versions", func(w http.ResponseWriter, r *http.Request) {
+		if !locked {
+			t.Error("state uploaded to an unlocked workspace")
+		}
+		var body struct {
+			Data struct {
+				Attributes map[string]interface{} `json:"attributes"`
+			} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		uploaded = body.Data.Attributes
+		fmt.Fprint(w, `{"data": {"id": "sv-2", "attributes": {"serial": 6}}}`)
+	})
+
+	c := New("token", server.URL)
+
+	for raw, want := range map[string]error{
+		`{"version": 4, "serial": 5, "lineage": "abc"}`: ErrStateSerialConflict,
+		`{"version": 4, "serial": 6, "lineage": "xyz"}`: ErrStateLineageMismatch,
+	} {
+		if _, err := c.CreateStateVersion("ws-1", []byte(raw), CreateStateVersionOptions{}); err != want {
+			t.Errorf("expected %v, got %v", want, err)
+		}
+		if locked {
+			t.Error("workspace left locked")
+		}
+	}
+
+	raw := []byte(`{"version": 4, "serial": 6, "lineage": "abc"}`)
+	sv, err := c.CreateStateVersion("ws-1", raw, CreateStateVersionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sv.ID != "sv-2" || locked {
+		t.Errorf("got %v, locked=%v", sv.ID, locked)
+	}
+
+	sum := md5.Sum(raw)
+	if uploaded["md5"] != hex.EncodeToString(sum[:]) || uploaded["state"] != base64.StdEncoding.EncodeToString(raw) ||
+		uploaded["serial"] != float64(6) || uploaded["lineage"] != "abc" {
+		t.Errorf("unexpected upload: %v", uploaded)
+	}
+
+	locked = true
+	if _, err := c.CreateStateVersion("ws-1", raw, CreateStateVersionOptions{}); err != ErrWorkspaceLocked {
+		t.Errorf("expected ErrWorkspaceLocked, got %v", err)
+	}
+}
+
+func TestCreateStateVersionRejected(t *testing.T) {
+	var locked bool
+	uploads := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workspaces/ws-1/actions/lock", func(w http.ResponseWriter, r *http.Request) {
+		locked = true
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/actions/unlock", func(w http.ResponseWriter, r *http.Request) {
+		locked = false
+		fmt.Fprint(w, `{"data": {"id": "ws-1"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-1/state-versions", func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// A rejected state is not uploaded again, so the workspace is unlocked
+	// right away
+	start := time.Now()
+	raw := []byte(`{"version": 4, "serial": 6, "lineage": "abc"}`)
+	_, err := New("token", server.URL).CreateStateVersion("ws-1", raw, CreateStateVersionOptions{Force: true})
+	if err != ErrBadStatus {
+		t.Errorf("expected ErrBadStatus, got %v", err)
+	}
+	if uploads != 1 || locked {
+		t.Errorf("got %d uploads, locked=%v", uploads, locked)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v", elapsed)
+	}
+}
@@ -0,0 +1,425 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrTeamNotFound is returned when a team does not exist or is not visible
+// to the token
+var ErrTeamNotFound = errors.New("Team not found")
+
+// ErrTeamAccessNotFound is returned when a team access does not exist or is
+// not visible to the token
+var ErrTeamAccessNotFound = errors.New("Team access not found")
+
+// teamPayload is the writable subset of a team
+type teamPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name               *string                 `json:"name,omitempty"`
+		Visibility         *string                 `json:"visibility,omitempty"`
+		SSOTeamID          *string                 `json:"sso-team-id,omitempty"`
+		OrganizationAccess *TeamOrganizationAccess `json:"organization-access,omitempty"`
+	} `json:"attributes"`
+}
+
+// accessPayload is the writable subset of a team access, on either a
+// workspace or a project
+type accessPayload struct {
+	Type          string        `json:"type"`
+	Attributes    interface{}   `json:"attributes"`
+	Relationships Relationships `json:"relationships,omitempty"`
+}
+
+// ListTeams lists all teams of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/teams
+func (c *Client) ListTeams(organization string) ([]Team, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/teams", organization)
+	teams, err := listPages[Team](c, path, nil)
+	if err == ErrNotFound {
+		return teams, ErrOrganizationNotFound
+	}
+	return teams, err
+}
+
+// GetTeam gets a specific team
+// Requires 1 request:
+// - /api/v2/teams/:teamID
+func (c *Client) GetTeam(teamID string) (Team, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s", teamID)
+
+	type wrapper struct {
+		Data Team `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Team{}, ErrTeamNotFound
+		}
+		return Team{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateTeam creates a new team
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/teams
+func (c *Client) CreateTeam(organization string, options CreateTeamOptions) (Team, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/teams", organization)
+
+	payload := teamPayload{Type: "teams"}
+	payload.Attributes.Name = &options.Name
+	payload.Attributes.OrganizationAccess = options.OrganizationAccess
+	if options.Visibility != "" {
+		payload.Attributes.Visibility = &options.Visibility
+	}
+	if options.SSOTeamID != "" {
+		payload.Attributes.SSOTeamID = &options.SSOTeamID
+	}
+
+	return c.writeTeam(http.MethodPost, path, payload)
+}
+
+// UpdateTeam updates the settings of a team
+// Requires 1 request:
+// - PATCH /api/v2/teams/:teamID
+func (c *Client) UpdateTeam(teamID string, options UpdateTeamOptions) (Team, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s", teamID)
+
+	payload := teamPayload{Type: "teams"}
+	payload.Attributes.Name = options.Name
+	payload.Attributes.Visibility = options.Visibility
+	payload.Attributes.SSOTeamID = options.SSOTeamID
+	payload.Attributes.OrganizationAccess = options.OrganizationAccess
+
+	return c.writeTeam(http.MethodPatch, path, payload)
+}
+
+func (c *Client) writeTeam(method, path string, payload teamPayload) (Team, error) {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return Team{}, err
+	}
+
+	type wrapperResp struct {
+		Data Team `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Team{}, ErrTeamNotFound
+		}
+		return Team{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteTeam deletes a team
+// Requires 1 request:
+// - DELETE /api/v2/teams/:teamID
+func (c *Client) DeleteTeam(teamID string) error {
+	path := fmt.Sprintf("/api/v2/teams/%s", teamID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrTeamNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListTeamMembers lists the users of a team
+// Requires 1 request:
+// - /api/v2/teams/:teamID?include=users
+func (c *Client) ListTeamMembers(teamID string) ([]User, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s", teamID)
+	q := url.Values{}
+	q.Set("include", "users")
+
+	type wrapper struct {
+		Data     Team   `json:"data"`
+		Included []User `json:"included"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, q, &resp); err != nil {
+		if err == ErrNotFound {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+
+	users := []User{}
+	for _, u := range resp.Included {
+		if u.Type == "users" {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// AddTeamMembers adds users to a team by username. The users must already
+// be members of the organization.
+// Requires 1 request:
+// - POST /api/v2/teams/:teamID/relationships/users
+func (c *Client) AddTeamMembers(teamID string, usernames ...string) error {
+	return c.updateTeamRelationship(http.MethodPost, teamID, "users", usernames)
+}
+
+// RemoveTeamMembers removes users from a team by username
+// Requires 1 request:
+// - DELETE /api/v2/teams/:teamID/relationships/users
+func (c *Client) RemoveTeamMembers(teamID string, usernames ...string) error {
+	return c.updateTeamRelationship(http.MethodDelete, teamID, "users", usernames)
+}
+
+// AddTeamMemberships adds organization members to a team by organization
+// membership ID, which also works for invited members who have not yet
+// accepted their invitation
+// Requires 1 request:
+// - POST /api/v2/teams/:teamID/relationships/organization-memberships
+func (c *Client) AddTeamMemberships(teamID string, membershipIDs ...string) error {
+	return c.updateTeamRelationship(http.MethodPost, teamID, "organization-memberships", membershipIDs)
+}
+
+// RemoveTeamMemberships removes organization members from a team by
+// organization membership ID
+// Requires 1 request:
+// - DELETE /api/v2/teams/:teamID/relationships/organization-memberships
+func (c *Client) RemoveTeamMemberships(teamID string, membershipIDs ...string) error {
+	return c.updateTeamRelationship(http.MethodDelete, teamID, "organization-memberships", membershipIDs)
+}
+
+func (c *Client) updateTeamRelationship(method, teamID, typ string, ids []string) error {
+	path := fmt.Sprintf("/api/v2/teams/%s/relationships/%s", teamID, typ)
+
+	b, err := json.Marshal(toMany(typ, ids))
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrTeamNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListTeamAccess lists the teams with access to a workspace
+// Requires P requests, where P is the number of pages
+// - /api/v2/team-workspaces?filter[workspace][id]=:workspaceID
+func (c *Client) ListTeamAccess(workspaceID string) ([]TeamAccess, error) {
+	q := url.Values{}
+	q.Set("filter[workspace][id]", workspaceID)
+	access, err := listPages[TeamAccess](c, "/api/v2/team-workspaces", q)
+	if err == ErrNotFound {
+		return access, ErrWorkspaceNotFound
+	}
+	return access, err
+}
+
+// GetTeamAccess gets a specific team access to a workspace
+// Requires 1 request:
+// - /api/v2/team-workspaces/:teamAccessID
+func (c *Client) GetTeamAccess(teamAccessID string) (TeamAccess, error) {
+	path := fmt.Sprintf("/api/v2/team-workspaces/%s", teamAccessID)
+
+	var access TeamAccess
+	if err := c.getAccess(path, &access); err != nil {
+		return TeamAccess{}, err
+	}
+	return access, nil
+}
+
+// AddTeamAccess grants a team access to a workspace
+// Requires 1 request:
+// - POST /api/v2/team-workspaces
+func (c *Client) AddTeamAccess(workspaceID, teamID string, options TeamAccessOptions) (TeamAccess, error) {
+	payload := accessPayload{
+		Type:       "team-workspaces",
+		Attributes: teamAccessAttributes(options),
+		Relationships: Relationships{
+			"workspace": {Data: RelationshipData{Type: "workspaces", ID: workspaceID}},
+			"team":      {Data: RelationshipData{Type: "teams", ID: teamID}},
+		},
+	}
+
+	var access TeamAccess
+	if err := c.writeAccess(http.MethodPost, "/api/v2/team-workspaces", payload, &access); err != nil {
+		return TeamAccess{}, err
+	}
+	return access, nil
+}
+
+// UpdateTeamAccess changes the access of a team to a workspace
+// Requires 1 request:
+// - PATCH /api/v2/team-workspaces/:teamAccessID
+func (c *Client) UpdateTeamAccess(teamAccessID string, options TeamAccessOptions) (TeamAccess, error) {
+	path := fmt.Sprintf("/api/v2/team-workspaces/%s", teamAccessID)
+	payload := accessPayload{
+		Type:       "team-workspaces",
+		Attributes: teamAccessAttributes(options),
+	}
+
+	var access TeamAccess
+	if err := c.writeAccess(http.MethodPatch, path, payload, &access); err != nil {
+		return TeamAccess{}, err
+	}
+	return access, nil
+}
+
+// RemoveTeamAccess revokes the access of a team to a workspace
+// Requires 1 request:
+// - DELETE /api/v2/team-workspaces/:teamAccessID
+func (c *Client) RemoveTeamAccess(teamAccessID string) error {
+	path := fmt.Sprintf("/api/v2/team-workspaces/%s", teamAccessID)
+	return c.deleteAccess(path)
+}
+
+// teamAccessAttributes only sends granular permissions for custom access,
+// which the API rejects otherwise
+func teamAccessAttributes(options TeamAccessOptions) TeamAccessAttributes {
+	attrs := TeamAccessAttributes{Access: options.Access}
+	if options.Access == AccessCustom {
+		attrs.WorkspacePermissions = options.Permissions
+	}
+	return attrs
+}
+
+// ListTeamProjectAccess lists the teams with access to a project
+// Requires P requests, where P is the number of pages
+// - /api/v2/team-projects?filter[project][id]=:projectID
+func (c *Client) ListTeamProjectAccess(projectID string) ([]TeamProjectAccess, error) {
+	q := url.Values{}
+	q.Set("filter[project][id]", projectID)
+	access, err := listPages[TeamProjectAccess](c, "/api/v2/team-projects", q)
+	if err == ErrNotFound {
+		return access, ErrProjectNotFound
+	}
+	return access, err
+}
+
+// GetTeamProjectAccess gets a specific team access to a project
+// Requires 1 request:
+// - /api/v2/team-projects/:teamProjectAccessID
+func (c *Client) GetTeamProjectAccess(teamProjectAccessID string) (TeamProjectAccess, error) {
+	path := fmt.Sprintf("/api/v2/team-projects/%s", teamProjectAccessID)
+
+	var access TeamProjectAccess
+	if err := c.getAccess(path, &access); err != nil {
+		return TeamProjectAccess{}, err
+	}
+	return access, nil
+}
+
+// AddTeamProjectAccess grants a team access to a project and its workspaces
+// Requires 1 request:
+// - POST /api/v2/team-projects
+func (c *Client) AddTeamProjectAccess(projectID, teamID string, options TeamProjectAccessOptions) (TeamProjectAccess, error) {
+	payload := accessPayload{
+		Type:       "team-projects",
+		Attributes: teamProjectAccessAttributes(options),
+		Relationships: Relationships{
+			"project": {Data: RelationshipData{Type: "projects", ID: projectID}},
+			"team":    {Data: RelationshipData{Type: "teams", ID: teamID}},
+		},
+	}
+
+	var access TeamProjectAccess
+	if err := c.writeAccess(http.MethodPost, "/api/v2/team-projects", payload, &access); err != nil {
+		return TeamProjectAccess{}, err
+	}
+	return access, nil
+}
+
+// UpdateTeamProjectAccess changes the access of a team to a project
+// Requires 1 request:
+// - PATCH /api/v2/team-projects/:teamProjectAccessID
+func (c *Client) UpdateTeamProjectAccess(teamProjectAccessID string, options TeamProjectAccessOptions) (TeamProjectAccess, error) {
+	path := fmt.Sprintf("/api/v2/team-projects/%s", teamProjectAccessID)
+	payload := accessPayload{
+		Type:       "team-projects",
+		Attributes: teamProjectAccessAttributes(options),
+	}
+
+	var access TeamProjectAccess
+	if err := c.writeAccess(http.MethodPatch, path, payload, &access); err != nil {
+		return TeamProjectAccess{}, err
+	}
+	return access, nil
+}
+
+// RemoveTeamProjectAccess revokes the access of a team to a project
+// Requires 1 request:
+// - DELETE /api/v2/team-projects/:teamProjectAccessID
+func (c *Client) RemoveTeamProjectAccess(teamProjectAccessID string) error {
+	path := fmt.Sprintf("/api/v2/team-projects/%s", teamProjectAccessID)
+	return c.deleteAccess(path)
+}
+
+func teamProjectAccessAttributes(options TeamProjectAccessOptions) TeamProjectAccessAttributes {
+	attrs := TeamProjectAccessAttributes{Access: options.Access}
+	if options.Access == AccessCustom {
+		attrs.ProjectAccess = options.ProjectAccess
+		attrs.WorkspaceAccess = options.WorkspaceAccess
+	}
+	return attrs
+}
+
+func (c *Client) getAccess(path string, recv interface{}) error {
+	resp := struct {
+		Data interface{} `json:"data"`
+	}{recv}
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return ErrTeamAccessNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) writeAccess(method, path string, payload accessPayload, recv interface{}) error {
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, &wrapper{Data: recv}); err != nil {
+		if err == ErrNotFound {
+			return ErrTeamAccessNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) deleteAccess(path string) error {
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrTeamAccessNotFound
+		}
+		return err
+	}
+	return nil
+}
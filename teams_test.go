@@ -0,0 +1,89 @@
+package tfe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAddTeamAccess(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/team-workspaces" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+		w.Write([]byte(`{"data": {"id": "tws-1", "attributes": {"access": "custom", "runs": "plan"}, "relationships": {"team": {"data": {"id": "team-1", "type": "teams"}}}}}`))
+	}))
+	defer server.Close()
+
+	runs, locking := "plan", true
+	access, err := New("token", server.URL).AddTeamAccess("ws-1", "team-1", TeamAccessOptions{
+		Access:      AccessCustom,
+		Permissions: WorkspacePermissions{Runs: &runs, WorkspaceLocking: &locking},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access.TeamID() != "team-1" || access.Attributes.Runs == nil || *access.Attributes.Runs != "plan" {
+		t.Errorf("got %+v", access)
+	}
+
+	want := map[string]interface{}{
+		"access":            "custom",
+		"runs":              "plan",
+		"workspace-locking": true,
+	}
+	data := got["data"].(map[string]interface{})
+	if !reflect.DeepEqual(data["attributes"], want) {
+		t.Errorf("got attributes %v", data["attributes"])
+	}
+	rels := data["relationships"].(map[string]interface{})
+	if ws := rels["workspace"].(map[string]interface{})["data"]; !reflect.DeepEqual(ws, map[string]interface{}{"id": "ws-1", "type": "workspaces"}) {
+		t.Errorf("got workspace relationship %v", ws)
+	}
+}
+
+func TestUpdateTeamAccessDisablesPermissions(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK, `{"data": {"id": "tws-1", "attributes": {"access": "custom"}}}`)
+
+	disabled := false
+	if _, err := New("token", server.URL).UpdateTeamAccess("tws-1", TeamAccessOptions{
+		Access:      AccessCustom,
+		Permissions: WorkspacePermissions{WorkspaceLocking: &disabled, RunTasks: &disabled},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Permissions set to false are sent, unset ones are left untouched
+	want := []recordedRequest{{
+		Method: http.MethodPatch,
+		Path:   "/api/v2/team-workspaces/tws-1",
+		Body: map[string]interface{}{"data": map[string]interface{}{
+			"type": "team-workspaces",
+			"attributes": map[string]interface{}{
+				"access":            "custom",
+				"workspace-locking": false,
+				"run-tasks":         false,
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %+v\nwant %+v", *requests, want)
+	}
+}
+
+func TestTeamAccessAttributesIgnoresPermissions(t *testing.T) {
+	runs := "apply"
+	attrs := teamAccessAttributes(TeamAccessOptions{
+		Access:      AccessWrite,
+		Permissions: WorkspacePermissions{Runs: &runs},
+	})
+	if attrs != (TeamAccessAttributes{Access: AccessWrite}) {
+		t.Errorf("got %+v", attrs)
+	}
+}
@@ -0,0 +1,663 @@
+package tfe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnknownVariablesFile is returned by LoadVariablesFile when the file
+// extension is not one of .tfvars, .tfvars.json or .env
+var ErrUnknownVariablesFile = errors.New("Unrecognized variables file type")
+
+// LoadVariablesFile reads a variables file from disk and parses it according
+// to its name:
+// - *.tfvars.json is parsed with ParseTFVarsJSON
+// - *.tfvars is parsed with ParseTFVars
+// - .env, .env.* and *.env are parsed with ParseDotEnv
+func LoadVariablesFile(path string) ([]CreateVariableOptions, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(base, ".tfvars.json"):
+		return ParseTFVarsJSON(src)
+	case strings.HasSuffix(base, ".tfvars"):
+		return ParseTFVars(src)
+	case base == ".env", strings.HasPrefix(base, ".env."), strings.HasSuffix(base, ".env"):
+		return ParseDotEnv(src)
+	}
+	return nil, ErrUnknownVariablesFile
+}
+
+// ParseTFVars parses the contents of an HCL .tfvars file into terraform
+// variable definitions, in the order they appear in the file.
+// Strings, numbers and bools are sent as plain values, while lists, maps and
+// objects keep their HCL source and are marked with HCL set.
+// Variables assigned null are skipped, as terraform treats them as unset.
+func ParseTFVars(src []byte) ([]CreateVariableOptions, error) {
+	p := &hclParser{src: src, line: 1}
+	vars := []CreateVariableOptions{}
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return vars, nil
+		}
+
+		key, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace(false)
+		if p.peek() != '=' {
+			return nil, p.errorf("expected '=' after %q", key)
+		}
+		p.pos++
+		p.skipSpace(false)
+
+		start := p.pos
+		val, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		raw := strings.TrimSpace(string(p.src[start:p.pos]))
+
+		p.skipSpace(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("unexpected %q after value of %q", p.peek(), key)
+		}
+
+		if val.null {
+			continue
+		}
+		v := CreateVariableOptions{
+			Key:      key,
+			Category: CategoryTerraform,
+			HCL:      val.complex,
+		}
+		if val.complex {
+			v.Value = raw
+		} else {
+			v.Value = val.str
+		}
+		vars = append(vars, v)
+	}
+}
+
+// ParseTFVarsJSON parses the contents of a .tfvars.json file into terraform
+// variable definitions, in the order they appear in the file.
+// Arrays and objects are converted to HCL and marked with HCL set.
+// Variables assigned null are skipped, as terraform treats them as unset.
+func ParseTFVarsJSON(src []byte) ([]CreateVariableOptions, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, errors.New("tfvars.json: root must be an object")
+	}
+
+	vars := []CreateVariableOptions{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, err
+		}
+
+		v := CreateVariableOptions{
+			Key:      key,
+			Category: CategoryTerraform,
+		}
+		switch val := val.(type) {
+		case nil:
+			continue
+		case string:
+			v.Value = val
+		case json.Number:
+			v.Value = val.String()
+		case bool:
+			v.Value = strconv.FormatBool(val)
+		default:
+			var buf bytes.Buffer
+			writeHCLValue(&buf, val, "")
+			v.Value = buf.String()
+			v.HCL = true
+		}
+		vars = append(vars, v)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// ParseDotEnv parses the contents of a dotenv file into environment variable
+// definitions, in the order they appear in the file.
+// Lines have the form KEY=value, optionally prefixed by "export". Values may
+// be single quoted (taken literally) or double quoted (supporting \n, \t, \"
+// and \\ escapes). Unquoted values end at a " #" comment.
+func ParseDotEnv(src []byte) ([]CreateVariableOptions, error) {
+	vars := []CreateVariableOptions{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), len(src)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "export ") {
+			text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		}
+
+		eq := strings.IndexByte(text, '=')
+		if eq < 1 {
+			return nil, fmt.Errorf("dotenv:%d: expected KEY=value", line)
+		}
+		key := strings.TrimSpace(text[:eq])
+		if !isEnvKey(key) {
+			return nil, fmt.Errorf("dotenv:%d: invalid variable name %q", line, key)
+		}
+
+		value, err := dotEnvValue(strings.TrimSpace(text[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("dotenv:%d: %v", line, err)
+		}
+
+		vars = append(vars, CreateVariableOptions{
+			Key:      key,
+			Value:    value,
+			Category: CategoryEnv,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func isEnvKey(key string) bool {
+	for i, r := range key {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return key != ""
+}
+
+func dotEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single quoted value")
+		}
+		return s[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				i++
+				if i == len(s) {
+					return "", errors.New("unterminated double quoted value")
+				}
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quoted value")
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// hclValue is the result of parsing a single HCL expression
+type hclValue struct {
+	str     string
+	complex bool
+	null    bool
+}
+
+// hclParser is a minimal parser for the literal subset of HCL allowed in
+// .tfvars files: strings, heredocs, numbers, bools, null, lists and objects
+type hclParser struct {
+	src  []byte
+	pos  int
+	line int
+}
+
+func (p *hclParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("tfvars:%d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *hclParser) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.src[p.pos:], []byte(s))
+}
+
+// skipSpace skips whitespace and comments, and newlines if newlines is set.
+// A line comment is skipped up to, but not including, its newline.
+func (p *hclParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '\n':
+			if !newlines {
+				return
+			}
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '#' || p.hasPrefix("//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case p.hasPrefix("/*"):
+			end := bytes.Index(p.src[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.line += bytes.Count(p.src[p.pos:p.pos+2+end], []byte("\n"))
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(!first && (c == '-' || (c >= '0' && c <= '9')))
+}
+
+func (p *hclParser) ident() (string, error) {
+	start := p.pos
+	for !p.eof() && isIdentByte(p.peek(), p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected identifier, got %q", p.peek())
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func (p *hclParser) value() (hclValue, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		s, err := p.quoted()
+		return hclValue{str: s}, err
+	case p.hasPrefix("<<"):
+		s, err := p.heredoc()
+		return hclValue{str: s}, err
+	case c == '[':
+		return hclValue{complex: true}, p.collection(']')
+	case c == '{':
+		return hclValue{complex: true}, p.collection('}')
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case isIdentByte(c, true):
+		word, _ := p.ident()
+		switch word {
+		case "true", "false":
+			return hclValue{str: word}, nil
+		case "null":
+			return hclValue{null: true}, nil
+		}
+		return hclValue{}, p.errorf("unexpected %q, only literal values are allowed", word)
+	case c == 0:
+		return hclValue{}, p.errorf("unexpected end of input")
+	}
+	return hclValue{}, p.errorf("unexpected %q", p.peek())
+}
+
+func (p *hclParser) number() (hclValue, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() {
+		c := p.peek()
+		if (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' ||
+			((c == '+' || c == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	num := string(p.src[start:p.pos])
+	if _, err := strconv.ParseFloat(num, 64); err != nil {
+		return hclValue{}, p.errorf("invalid number %q", num)
+	}
+	return hclValue{str: num}, nil
+}
+
+// quoted parses a double quoted string, decoding its escape sequences
+func (p *hclParser) quoted() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		switch {
+		case p.peek() == '"':
+			p.pos++
+			return b.String(), nil
+		case p.hasPrefix("$${"), p.hasPrefix("%%{"):
+			b.WriteByte(p.peek())
+			b.WriteByte('{')
+			p.pos += 3
+		case p.hasPrefix("${"), p.hasPrefix("%{"):
+			return "", p.errorf("template sequences are not allowed in variable values")
+		case p.peek() == '\\':
+			p.pos++
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(p.peek())
+			p.pos++
+		}
+	}
+}
+
+func (p *hclParser) escape(b *strings.Builder) error {
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// heredoc parses a <<MARKER or <<-MARKER heredoc. The result includes the
+// trailing newline of the last line, like HCL does.
+func (p *hclParser) heredoc() (string, error) {
+	p.pos += 2
+	indent := false
+	if p.peek() == '-' {
+		indent = true
+		p.pos++
+	}
+	marker, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	p.skipSpace(false)
+	if p.peek() != '\n' {
+		return "", p.errorf("expected newline after heredoc marker %q", marker)
+	}
+	p.pos++
+	p.line++
+
+	lines := []string{}
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated heredoc %q", marker)
+		}
+		end := bytes.IndexByte(p.src[p.pos:], '\n')
+		var text string
+		if end < 0 {
+			text = string(p.src[p.pos:])
+			p.pos = len(p.src)
+		} else {
+			text = string(p.src[p.pos : p.pos+end])
+			p.pos += end
+		}
+
+		if strings.TrimSpace(text) == marker {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(text, "\r"))
+		if !p.eof() {
+			p.pos++
+			p.line++
+		}
+	}
+
+	if indent {
+		trimCommonIndent(lines)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func trimCommonIndent(lines []string) {
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeftFunc(l, unicode.IsSpace))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	if common <= 0 {
+		return
+	}
+	for i, l := range lines {
+		if len(l) >= common {
+			lines[i] = l[common:]
+		} else {
+			lines[i] = ""
+		}
+	}
+}
+
+// collection parses a list or object up to the closing delimiter. Only the
+// syntax is validated, callers use the source text of the whole collection.
+func (p *hclParser) collection(closing byte) error {
+	p.pos++
+	for {
+		p.skipSpace(true)
+		switch p.peek() {
+		case closing:
+			p.pos++
+			return nil
+		case 0:
+			return p.errorf("expected %q", closing)
+		}
+
+		if closing == '}' {
+			if p.peek() == '"' {
+				if _, err := p.quoted(); err != nil {
+					return err
+				}
+			} else if _, err := p.ident(); err != nil {
+				return err
+			}
+			p.skipSpace(false)
+			if c := p.peek(); c != '=' && c != ':' {
+				return p.errorf("expected '=' or ':' in object, got %q", c)
+			}
+			p.pos++
+			p.skipSpace(true)
+		}
+
+		if _, err := p.value(); err != nil {
+			return err
+		}
+
+		p.skipSpace(false)
+		switch p.peek() {
+		case ',', '\n':
+			p.pos++
+			if p.src[p.pos-1] == '\n' {
+				p.line++
+			}
+		case closing:
+		default:
+			return p.errorf("expected ',' or %q, got %q", closing, p.peek())
+		}
+	}
+}
+
+// writeHCLValue writes a decoded JSON value as an HCL expression
+func writeHCLValue(w io.Writer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case nil:
+		io.WriteString(w, "null")
+	case bool:
+		io.WriteString(w, strconv.FormatBool(v))
+	case json.Number:
+		io.WriteString(w, v.String())
+	case string:
+		io.WriteString(w, quoteHCL(v))
+	case []interface{}:
+		if len(v) == 0 {
+			io.WriteString(w, "[]")
+			return
+		}
+		io.WriteString(w, "[\n")
+		for _, e := range v {
+			io.WriteString(w, indent+"  ")
+			writeHCLValue(w, e, indent+"  ")
+			io.WriteString(w, ",\n")
+		}
+		io.WriteString(w, indent+"]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			io.WriteString(w, "{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		io.WriteString(w, "{\n")
+		for _, k := range keys {
+			io.WriteString(w, indent+"  ")
+			if isHCLIdent(k) {
+				io.WriteString(w, k)
+			} else {
+				io.WriteString(w, quoteHCL(k))
+			}
+			io.WriteString(w, " = ")
+			writeHCLValue(w, v[k], indent+"  ")
+			io.WriteString(w, "\n")
+		}
+		io.WriteString(w, indent+"}")
+	}
+}
+
+func isHCLIdent(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i], i == 0) {
+			return false
+		}
+	}
+	return s != "" && s != "true" && s != "false" && s != "null"
+}
+
+func quoteHCL(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package tfe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTFVars(t *testing.T) {
+	src := `
+# comment
+region = "us-west-2" // trailing comment
+count  = 3
+enabled = true
+unset = null
+escaped = "a\"b\n$${c}"
+zones = [
+  "a",
+  "b", # inline
+]
+tags = { Name = "x", "team-name": "infra" }
+policy = <<-EOT
+    line one
+      line two
+    EOT
+`
+	got, err := ParseTFVars([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CreateVariableOptions{
+		{Key: "region", Value: "us-west-2", Category: CategoryTerraform},
+		{Key: "count", Value: "3", Category: CategoryTerraform},
+		{Key: "enabled", Value: "true", Category: CategoryTerraform},
+		{Key: "escaped", Value: "a\"b\n${c}", Category: CategoryTerraform},
+		{Key: "zones", Value: "[\n  \"a\",\n  \"b\", # inline\n]", Category: CategoryTerraform, HCL: true},
+		{Key: "tags", Value: `{ Name = "x", "team-name": "infra" }`, Category: CategoryTerraform, HCL: true},
+		{Key: "policy", Value: "line one\n  line two\n", Category: CategoryTerraform},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTFVarsErrors(t *testing.T) {
+	for _, src := range []string{
+		`a = "${var.b}"`,
+		`a = foo`,
+		`a = [1, 2`,
+		`a "b"`,
+		`a = 1 b = 2`,
+	} {
+		if _, err := ParseTFVars([]byte(src)); err == nil {
+			t.Errorf("expected error parsing %q", src)
+		}
+	}
+}
+
+func TestParseTFVarsJSON(t *testing.T) {
+	src := `{"region": "us-west-2", "count": 3, "enabled": false, "unset": null,
+		"zones": ["a", "b"], "tags": {"Name": "x", "team-name": "${literal}"}}`
+
+	got, err := ParseTFVarsJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CreateVariableOptions{
+		{Key: "region", Value: "us-west-2", Category: CategoryTerraform},
+		{Key: "count", Value: "3", Category: CategoryTerraform},
+		{Key: "enabled", Value: "false", Category: CategoryTerraform},
+		{Key: "zones", Value: "[\n  \"a\",\n  \"b\",\n]", Category: CategoryTerraform, HCL: true},
+		{Key: "tags", Value: "{\n  Name = \"x\"\n  team-name = \"$${literal}\"\n}", Category: CategoryTerraform, HCL: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+
+	// The generated HCL must be readable by the tfvars parser
+	for _, v := range want {
+		if !v.HCL {
+			continue
+		}
+		if _, err := ParseTFVars([]byte(v.Key + " = " + v.Value)); err != nil {
+			t.Errorf("generated invalid HCL for %s: %v", v.Key, err)
+		}
+	}
+}
+
+func TestParseDotEnv(t *testing.T) {
+	src := `
+# comment
+AWS_REGION=us-east-1
+export TOKEN = abc # trailing
+SINGLE='a $b # c'
+DOUBLE="line\nnext"
+EMPTY=
+`
+	got, err := ParseDotEnv([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CreateVariableOptions{
+		{Key: "AWS_REGION", Value: "us-east-1", Category: CategoryEnv},
+		{Key: "TOKEN", Value: "abc", Category: CategoryEnv},
+		{Key: "SINGLE", Value: "a $b # c", Category: CategoryEnv},
+		{Key: "DOUBLE", Value: "line\nnext", Category: CategoryEnv},
+		{Key: "EMPTY", Value: "", Category: CategoryEnv},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+
+	if _, err := ParseDotEnv([]byte("1BAD=x")); err == nil {
+		t.Error("expected error for invalid variable name")
+	}
+}
@@ -0,0 +1,116 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrTokenValidation is returned when a rotated token cannot authenticate
+var ErrTokenValidation = errors.New("Token validation failed")
+
+// TokenSink receives a newly created token, e.g. to write it to a file or a
+// secret store, before the previous token is revoked
+type TokenSink func(ctx context.Context, token AuthenticationToken) error
+
+// FileTokenSink writes tokens to a file readable only by its owner,
+// atomically replacing the previous token
+func FileTokenSink(path string) TokenSink {
+	return func(ctx context.Context, token AuthenticationToken) error {
+		tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+
+		if _, err := tmp.WriteString(token.Attributes.Token.Reveal()); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp.Name(), path)
+	}
+}
+
+// RotateTokenOptions controls RotateTeamToken
+type RotateTokenOptions struct {
+	// Description of the new token
+	Description string
+
+	// ExpiresIn is the lifetime of the new token, which never expires when
+	// zero
+	ExpiresIn time.Duration
+
+	// Validate checks the new token with a client authenticated by it. It
+	// defaults to fetching the account details.
+	Validate func(c *Client) error
+
+	// Rollback, when set, is called if the new token fails validation after
+	// the sink received it, e.g. to restore the previous token in the sink
+	Rollback func(ctx context.Context) error
+}
+
+// RotateTeamToken replaces a team token: it creates a new token, hands it to
+// sink, validates it with an authenticated call, then revokes the old token.
+// An empty oldTokenID only provisions a new token.
+// When the sink or the validation fails, the new token is revoked, calling
+// options.Rollback first if the sink already received it, and the old token
+// is left untouched. The new token is also returned when revoking the old one
+// fails, as the sink holds it.
+// Requires 3 requests, plus those of options.Validate:
+// - POST /api/v2/teams/:teamID/authentication-tokens
+// - /api/v2/account/details
+// - DELETE /api/v2/authentication-tokens/:oldTokenID
+func (c *Client) RotateTeamToken(ctx context.Context, teamID, oldTokenID string, sink TokenSink, options RotateTokenOptions) (AuthenticationToken, error) {
+	create := CreateTokenOptions{Description: options.Description}
+	if options.ExpiresIn > 0 {
+		create.ExpiredAt = time.Now().Add(options.ExpiresIn)
+	}
+
+	token, err := c.CreateTeamToken(teamID, create)
+	if err != nil {
+		return AuthenticationToken{}, err
+	}
+
+	// revoke undoes the rotation, reporting failures along with the cause
+	revoke := func(cause error) error {
+		if err := c.DeleteToken(token.ID); err != nil {
+			return fmt.Errorf("%w, and revoking the new token %s failed: %v", cause, token.ID, err)
+		}
+		return cause
+	}
+
+	if err := sink(ctx, token); err != nil {
+		return AuthenticationToken{}, revoke(fmt.Errorf("storing the new token: %w", err))
+	}
+
+	validate := options.Validate
+	if validate == nil {
+		validate = func(c *Client) error {
+			_, err := c.GetCurrentUser()
+			return err
+		}
+	}
+	if err := validate(NewWithClient(token.Attributes.Token.Reveal(), c.BaseURL, c.client)); err != nil {
+		cause := fmt.Errorf("%w: %v", ErrTokenValidation, err)
+		if options.Rollback != nil {
+			if err := options.Rollback(ctx); err != nil {
+				cause = fmt.Errorf("%w, and rolling back the sink failed: %v", cause, err)
+			}
+		}
+		return AuthenticationToken{}, revoke(cause)
+	}
+
+	if oldTokenID != "" {
+		if err := c.DeleteToken(oldTokenID); err != nil {
+			return token, fmt.Errorf("revoking the old token %s: %w", oldTokenID, err)
+		}
+	}
+	return token, nil
+}
@@ -0,0 +1,75 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRotateTeamToken(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/teams/team-1/authentication-tokens":
+			fmt.Fprint(w, `{"data": {"id": "at-new", "attributes": {"token": "new-token"}}}`)
+		case r.URL.Path == "/api/v2/account/details":
+			if r.Header.Get("Authorization") != "Bearer new-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"data": {"id": "user-1", "attributes": {"is-service-account": true}}}`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, filepath.Base(r.URL.Path))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	c := New("token", server.URL)
+
+	path := filepath.Join(t.TempDir(), "token")
+	token, err := c.RotateTeamToken(context.Background(), "team-1", "at-old", FileTokenSink(path), RotateTokenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.ID != "at-new" {
+		t.Errorf("got %+v", token)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "new-token" {
+		t.Errorf("got token file %q", b)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("got token file mode %v", fi.Mode())
+	}
+	if !reflect.DeepEqual(deleted, []string{"at-old"}) {
+		t.Errorf("got deleted %v", deleted)
+	}
+
+	// A failed validation rolls back the sink and revokes the new token
+	deleted = nil
+	var rolledBack bool
+	_, err = c.RotateTeamToken(context.Background(), "team-1", "at-old", FileTokenSink(path), RotateTokenOptions{
+		Validate: func(c *Client) error { return ErrUnauthorized },
+		Rollback: func(ctx context.Context) error {
+			rolledBack = true
+			return nil
+		},
+	})
+	if !errors.Is(err, ErrTokenValidation) {
+		t.Errorf("got %v, want ErrTokenValidation", err)
+	}
+	if !rolledBack {
+		t.Error("sink not rolled back")
+	}
+	if !reflect.DeepEqual(deleted, []string{"at-new"}) {
+		t.Errorf("got deleted %v", deleted)
+	}
+}
@@ -0,0 +1,204 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrTokenNotFound is returned when an API token does not exist or is not
+// visible to the token
+var ErrTokenNotFound = errors.New("Token not found")
+
+// tokenPayload is the writable subset of an API token
+type tokenPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Description string     `json:"description,omitempty"`
+		ExpiredAt   *time.Time `json:"expired-at,omitempty"`
+	} `json:"attributes"`
+}
+
+// GetCurrentUser gets the account details of the user owning the token,
+// including its two-factor authentication status. Team and organization
+// tokens are reported as service accounts.
+// Requires 1 request:
+// - /api/v2/account/details
+func (c *Client) GetCurrentUser() (User, error) {
+	type wrapper struct {
+		Data User `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", "/api/v2/account/details", nil, nil, &resp); err != nil {
+		return User{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// ListUserTokens lists the API tokens of a user, without their values
+// Requires P requests, where P is the number of pages
+// - /api/v2/users/:userID/authentication-tokens
+func (c *Client) ListUserTokens(userID string) ([]AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/users/%s/authentication-tokens", userID)
+	return listPages[AuthenticationToken](c, path, nil)
+}
+
+// CreateUserToken creates an API token for a user. The returned token holds
+// its value, which cannot be retrieved again.
+// Requires 1 request:
+// - POST /api/v2/users/:userID/authentication-tokens
+func (c *Client) CreateUserToken(userID string, options CreateTokenOptions) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/users/%s/authentication-tokens", userID)
+	return c.writeToken(path, options)
+}
+
+// GetToken gets a specific user or team API token, without its value
+// Requires 1 request:
+// - /api/v2/authentication-tokens/:tokenID
+func (c *Client) GetToken(tokenID string) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/authentication-tokens/%s", tokenID)
+	return c.getToken(path)
+}
+
+// DeleteToken revokes a user or team API token by ID
+// Requires 1 request:
+// - DELETE /api/v2/authentication-tokens/:tokenID
+func (c *Client) DeleteToken(tokenID string) error {
+	path := fmt.Sprintf("/api/v2/authentication-tokens/%s", tokenID)
+	return c.deleteToken(path)
+}
+
+// ListTeamTokens lists the API tokens of a team, without their values
+// Requires P requests, where P is the number of pages
+// - /api/v2/teams/:teamID/authentication-tokens
+func (c *Client) ListTeamTokens(teamID string) ([]AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s/authentication-tokens", teamID)
+	tokens, err := listPages[AuthenticationToken](c, path, nil)
+	if err == ErrNotFound {
+		return tokens, ErrTeamNotFound
+	}
+	return tokens, err
+}
+
+// CreateTeamToken creates an additional API token for a team, leaving its
+// other tokens valid. The returned token holds its value, which cannot be
+// retrieved again. Revoke it with DeleteToken.
+// Requires 1 request:
+// - POST /api/v2/teams/:teamID/authentication-tokens
+func (c *Client) CreateTeamToken(teamID string, options CreateTokenOptions) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s/authentication-tokens", teamID)
+	return c.writeToken(path, options)
+}
+
+// GetTeamToken gets the legacy API token of a team, without its value
+// Requires 1 request:
+// - /api/v2/teams/:teamID/authentication-token
+func (c *Client) GetTeamToken(teamID string) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s/authentication-token", teamID)
+	return c.getToken(path)
+}
+
+// RegenerateTeamToken creates the legacy API token of a team, immediately
+// revoking the previous one. The returned token holds its value, which
+// cannot be retrieved again.
+// Requires 1 request:
+// - POST /api/v2/teams/:teamID/authentication-token
+func (c *Client) RegenerateTeamToken(teamID string, options CreateTokenOptions) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/teams/%s/authentication-token", teamID)
+	return c.writeToken(path, options)
+}
+
+// DeleteTeamToken revokes the legacy API token of a team
+// Requires 1 request:
+// - DELETE /api/v2/teams/:teamID/authentication-token
+func (c *Client) DeleteTeamToken(teamID string) error {
+	path := fmt.Sprintf("/api/v2/teams/%s/authentication-token", teamID)
+	return c.deleteToken(path)
+}
+
+// GetOrganizationToken gets the API token of an organization, without its
+// value
+// Requires 1 request:
+// - /api/v2/organizations/:organizationName/authentication-token
+func (c *Client) GetOrganizationToken(organization string) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/authentication-token", organization)
+	return c.getToken(path)
+}
+
+// RegenerateOrganizationToken creates the API token of an organization,
+// immediately revoking the previous one. The returned token holds its value,
+// which cannot be retrieved again. Only ExpiredAt applies to organization
+// tokens.
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/authentication-token
+func (c *Client) RegenerateOrganizationToken(organization string, options CreateTokenOptions) (AuthenticationToken, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/authentication-token", organization)
+	return c.writeToken(path, CreateTokenOptions{ExpiredAt: options.ExpiredAt})
+}
+
+// DeleteOrganizationToken revokes the API token of an organization
+// Requires 1 request:
+// - DELETE /api/v2/organizations/:organizationName/authentication-token
+func (c *Client) DeleteOrganizationToken(organization string) error {
+	path := fmt.Sprintf("/api/v2/organizations/%s/authentication-token", organization)
+	return c.deleteToken(path)
+}
+
+func (c *Client) getToken(path string) (AuthenticationToken, error) {
+	type wrapper struct {
+		Data AuthenticationToken `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return AuthenticationToken{}, ErrTokenNotFound
+		}
+		return AuthenticationToken{}, err
+	}
+
+	return resp.Data, nil
+}
+
+func (c *Client) writeToken(path string, options CreateTokenOptions) (AuthenticationToken, error) {
+	payload := tokenPayload{Type: "authentication-tokens"}
+	payload.Attributes.Description = options.Description
+	if !options.ExpiredAt.IsZero() {
+		expiredAt := options.ExpiredAt.UTC()
+		payload.Attributes.ExpiredAt = &expiredAt
+	}
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return AuthenticationToken{}, err
+	}
+
+	type wrapperResp struct {
+		Data AuthenticationToken `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		return AuthenticationToken{}, err
+	}
+
+	return resp.Data, nil
+}
+
+func (c *Client) deleteToken(path string) error {
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrTokenNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package tfe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateTeamToken(t *testing.T) {
+	var got map[string]map[string]map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/teams/team-1/authentication-tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+		fmt.Fprint(w, `{"data": {"id": "at-1", "type": "authentication-tokens", "attributes": {"description": "ci", "token": "abc.atlasv1.xyz", "expired-at": "2026-01-01T00:00:00Z"}}}`)
+	}))
+	defer server.Close()
+
+	expiry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	token, err := New("token", server.URL).CreateTeamToken("team-1", CreateTokenOptions{Description: "ci", ExpiredAt: expiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := got["data"]["attributes"]
+	if attrs["description"] != "ci" || attrs["expired-at"] != "2026-01-01T00:00:00Z" {
+		t.Errorf("got attributes %v", attrs)
+	}
+	if token.Attributes.Token.Reveal() != "abc.atlasv1.xyz" {
+		t.Errorf("got token %q", token.Attributes.Token.Reveal())
+	}
+	if s := fmt.Sprintf("%+v", token); strings.Contains(s, "xyz") {
+		t.Errorf("token value leaked: %s", s)
+	}
+	if token.Attributes.ExpiredAt == nil || !token.Attributes.ExpiredAt.Equal(expiry) {
+		t.Errorf("got expiry %v", token.Attributes.ExpiredAt)
+	}
+}
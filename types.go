@@ -1,12 +1,283 @@
 package tfe
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
 
 // Organization is a Terraform Enterprise organization
 type Organization struct {
-	ID            string        `json:"id"`
-	Links         Links         `json:"links"`
-	Relationships Relationships `json:"relationships"`
+	ID            string                 `json:"id"`
+	Type          string                 `json:"type"`
+	Attributes    OrganizationAttributes `json:"attributes"`
+	Links         Links                  `json:"links"`
+	Relationships Relationships          `json:"relationships"`
+}
+
+type OrganizationAttributes struct {
+	Name                   string          `json:"name"`
+	Email                  string          `json:"email"`
+	ExternalID             string          `json:"external-id"`
+	CreatedAt              time.Time       `json:"created-at"`
+	SessionTimeout         int             `json:"session-timeout"`
+	SessionRemember        int             `json:"session-remember"`
+	CollaboratorAuthPolicy string          `json:"collaborator-auth-policy"`
+	CostEstimationEnabled  bool            `json:"cost-estimation-enabled"`
+	DefaultExecutionMode   string          `json:"default-execution-mode"`
+	TwoFactorConformant    bool            `json:"two-factor-conformant"`
+	SAMLEnabled            bool            `json:"saml-enabled"`
+	OwnersTeamSAMLRoleID   string          `json:"owners-team-saml-role-id"`
+	Permissions            map[string]bool `json:"permissions"`
+}
+
+// OrganizationEntitlements lists the features available to an organization
+type OrganizationEntitlements struct {
+	ID         string                             `json:"id"`
+	Type       string                             `json:"type"`
+	Attributes OrganizationEntitlementsAttributes `json:"attributes"`
+}
+
+type OrganizationEntitlementsAttributes struct {
+	Agents                bool `json:"agents"`
+	AuditLogging          bool `json:"audit-logging"`
+	CostEstimation        bool `json:"cost-estimation"`
+	Operations            bool `json:"operations"`
+	PrivateModuleRegistry bool `json:"private-module-registry"`
+	RunTasks              bool `json:"run-tasks"`
+	Sentinel              bool `json:"sentinel"`
+	SingleSignOn          bool `json:"sso"`
+	StateStorage          bool `json:"state-storage"`
+	Teams                 bool `json:"teams"`
+	VCSIntegrations       bool `json:"vcs-integrations"`
+}
+
+// OrganizationCapacity is the number of runs an organization has queued and
+// running
+type OrganizationCapacity struct {
+	ID         string                         `json:"id"`
+	Type       string                         `json:"type"`
+	Attributes OrganizationCapacityAttributes `json:"attributes"`
+}
+
+type OrganizationCapacityAttributes struct {
+	Pending int `json:"pending"`
+	Running int `json:"running"`
+}
+
+// Team is a group of organization members sharing access to workspaces and
+// projects
+type Team struct {
+	ID            string         `json:"id"`
+	Type          string         `json:"type"`
+	Attributes    TeamAttributes `json:"attributes"`
+	Links         Links          `json:"links"`
+	Relationships Relationships  `json:"relationships"`
+}
+
+type TeamAttributes struct {
+	Name               string                 `json:"name"`
+	UsersCount         int                    `json:"users-count"`
+	Visibility         string                 `json:"visibility"`
+	SSOTeamID          string                 `json:"sso-team-id"`
+	OrganizationAccess TeamOrganizationAccess `json:"organization-access"`
+	Permissions        map[string]bool        `json:"permissions"`
+}
+
+// TeamOrganizationAccess holds the organization-wide permissions of a team
+type TeamOrganizationAccess struct {
+	ManagePolicies    bool `json:"manage-policies"`
+	ManageWorkspaces  bool `json:"manage-workspaces"`
+	ManageVCSSettings bool `json:"manage-vcs-settings"`
+	ManageProviders   bool `json:"manage-providers"`
+	ManageModules     bool `json:"manage-modules"`
+	ManageRunTasks    bool `json:"manage-run-tasks"`
+	ManageProjects    bool `json:"manage-projects"`
+	ManageMembership  bool `json:"manage-membership"`
+	ReadWorkspaces    bool `json:"read-workspaces"`
+	ReadProjects      bool `json:"read-projects"`
+}
+
+// User is a Terraform Enterprise user account
+type User struct {
+	ID            string         `json:"id"`
+	Type          string         `json:"type"`
+	Attributes    UserAttributes `json:"attributes"`
+	Links         Links          `json:"links"`
+	Relationships Relationships  `json:"relationships"`
+}
+
+type UserAttributes struct {
+	Username         string          `json:"username"`
+	Email            string          `json:"email"`
+	UnconfirmedEmail string          `json:"unconfirmed-email"`
+	AvatarURL        string          `json:"avatar-url"`
+	IsServiceAccount bool            `json:"is-service-account"`
+	IsSiteAdmin      bool            `json:"is-site-admin"`
+	AuthMethod       string          `json:"auth-method"`
+	TwoFactor        TwoFactor       `json:"two-factor"`
+	Permissions      map[string]bool `json:"permissions"`
+}
+
+// TwoFactor is the two-factor authentication status of a user, only
+// reported for the current user
+type TwoFactor struct {
+	Enabled  bool `json:"enabled"`
+	Verified bool `json:"verified"`
+}
+
+// AuthenticationToken is a user, team or organization API token. Token is
+// only set when the token is created or regenerated, the API never returns
+// it afterwards.
+type AuthenticationToken struct {
+	ID            string                        `json:"id"`
+	Type          string                        `json:"type"`
+	Attributes    AuthenticationTokenAttributes `json:"attributes"`
+	Relationships Relationships                 `json:"relationships"`
+}
+
+type AuthenticationTokenAttributes struct {
+	Description string     `json:"description"`
+	Token       Secret     `json:"token"`
+	CreatedAt   time.Time  `json:"created-at"`
+	LastUsedAt  *time.Time `json:"last-used-at"`
+	ExpiredAt   *time.Time `json:"expired-at"`
+}
+
+// Organization membership statuses
+const (
+	MembershipActive  = "active"
+	MembershipInvited = "invited"
+)
+
+// OrganizationMembership links a user, or an invited email address, to an
+// organization
+type OrganizationMembership struct {
+	ID            string                           `json:"id"`
+	Type          string                           `json:"type"`
+	Attributes    OrganizationMembershipAttributes `json:"attributes"`
+	Relationships Relationships                    `json:"relationships"`
+}
+
+// UserID returns the ID of the member
+func (m OrganizationMembership) UserID() string {
+	return m.Relationships["user"].Data.ID
+}
+
+// TeamIDs returns the IDs of the teams of the member
+func (m OrganizationMembership) TeamIDs() []string {
+	return m.Relationships.IDs("teams")
+}
+
+type OrganizationMembershipAttributes struct {
+	Status string `json:"status"`
+	Email  string `json:"email"`
+}
+
+// Project is a group of workspaces of an organization
+type Project struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Attributes    ProjectAttributes `json:"attributes"`
+	Relationships Relationships     `json:"relationships"`
+}
+
+type ProjectAttributes struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+// Team access levels, on workspaces and projects. AccessMaintain only applies
+// to projects, and AccessPlan only to workspaces.
+const (
+	AccessRead     = "read"
+	AccessPlan     = "plan"
+	AccessWrite    = "write"
+	AccessMaintain = "maintain"
+	AccessAdmin    = "admin"
+	AccessCustom   = "custom"
+)
+
+// TeamAccess grants a team access to a workspace
+type TeamAccess struct {
+	ID            string               `json:"id"`
+	Type          string               `json:"type"`
+	Attributes    TeamAccessAttributes `json:"attributes"`
+	Relationships Relationships        `json:"relationships"`
+}
+
+// TeamID returns the ID of the team granted access
+func (ta TeamAccess) TeamID() string {
+	return ta.Relationships["team"].Data.ID
+}
+
+// WorkspaceID returns the ID of the workspace the team has access to
+func (ta TeamAccess) WorkspaceID() string {
+	return ta.Relationships["workspace"].Data.ID
+}
+
+type TeamAccessAttributes struct {
+	Access string `json:"access"`
+	WorkspacePermissions
+}
+
+// WorkspacePermissions are the granular permissions of AccessCustom on a
+// workspace. The fields are also reported, read-only, for the other access
+// levels. Nil fields are left untouched when adding or updating a team
+// access.
+type WorkspacePermissions struct {
+	Runs             *string `json:"runs,omitempty" yaml:"runs,omitempty"`                     // read, plan or apply
+	Variables        *string `json:"variables,omitempty" yaml:"variables,omitempty"`           // none, read or write
+	StateVersions    *string `json:"state-versions,omitempty" yaml:"state-versions,omitempty"` // none, read-outputs, read or write
+	SentinelMocks    *string `json:"sentinel-mocks,omitempty" yaml:"sentinel-mocks,omitempty"` // none or read
+	WorkspaceLocking *bool   `json:"workspace-locking,omitempty" yaml:"workspace-locking,omitempty"`
+	RunTasks         *bool   `json:"run-tasks,omitempty" yaml:"run-tasks,omitempty"`
+}
+
+// TeamProjectAccess grants a team access to a project and its workspaces
+type TeamProjectAccess struct {
+	ID            string                      `json:"id"`
+	Type          string                      `json:"type"`
+	Attributes    TeamProjectAccessAttributes `json:"attributes"`
+	Relationships Relationships               `json:"relationships"`
+}
+
+// TeamID returns the ID of the team granted access
+func (tpa TeamProjectAccess) TeamID() string {
+	return tpa.Relationships["team"].Data.ID
+}
+
+// ProjectID returns the ID of the project the team has access to
+func (tpa TeamProjectAccess) ProjectID() string {
+	return tpa.Relationships["project"].Data.ID
+}
+
+type TeamProjectAccessAttributes struct {
+	Access          string                       `json:"access"`
+	ProjectAccess   *ProjectPermissions          `json:"project-access,omitempty"`
+	WorkspaceAccess *ProjectWorkspacePermissions `json:"workspace-access,omitempty"`
+}
+
+// ProjectPermissions are the granular permissions of AccessCustom on the
+// project itself. Nil fields are left untouched.
+type ProjectPermissions struct {
+	Settings *string `json:"settings,omitempty" yaml:"settings,omitempty"` // read, update or delete
+	Teams    *string `json:"teams,omitempty" yaml:"teams,omitempty"`       // none, read or manage
+}
+
+// ProjectWorkspacePermissions are the granular permissions of AccessCustom
+// on the workspaces of a project. Nil fields are left untouched.
+type ProjectWorkspacePermissions struct {
+	Runs          *string `json:"runs,omitempty" yaml:"runs,omitempty"`                     // read, plan or apply
+	Variables     *string `json:"variables,omitempty" yaml:"variables,omitempty"`           // none, read or write
+	StateVersions *string `json:"state-versions,omitempty" yaml:"state-versions,omitempty"` // none, read-outputs, read or write
+	SentinelMocks *string `json:"sentinel-mocks,omitempty" yaml:"sentinel-mocks,omitempty"` // none or read
+	Create        *bool   `json:"create,omitempty" yaml:"create,omitempty"`
+	Move          *bool   `json:"move,omitempty" yaml:"move,omitempty"`
+	Locking       *bool   `json:"locking,omitempty" yaml:"locking,omitempty"`
+	Delete        *bool   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	RunTasks      *bool   `json:"run-tasks,omitempty" yaml:"run-tasks,omitempty"`
 }
 
 // Workspace is a Terraform Enterprise workspace
@@ -40,11 +311,33 @@ type Variable struct {
 }
 
 type VariableAttributes struct {
-	Key       string `json:"key"`
-	Value     string `json:"value"`
-	Category  string `json:"category"`
-	HCL       bool   `json:"hcl"`
-	Sensitive bool   `json:"sensitive"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	HCL         bool   `json:"hcl"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// VariableSet is a Terraform Enterprise variable set, a group of variables
+// shared by several workspaces and projects
+type VariableSet struct {
+	ID            string                `json:"id"`
+	Type          string                `json:"type"`
+	Attributes    VariableSetAttributes `json:"attributes"`
+	Relationships Relationships         `json:"relationships"`
+	Links         Links                 `json:"links"`
+}
+
+type VariableSetAttributes struct {
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Global         bool      `json:"global"`
+	Priority       bool      `json:"priority"`
+	UpdatedAt      time.Time `json:"updated-at"`
+	VarCount       int       `json:"var-count"`
+	WorkspaceCount int       `json:"workspace-count"`
+	ProjectCount   int       `json:"project-count"`
 }
 
 // The TFE API returns inconsistent schema for the Relationship objects
@@ -56,6 +349,7 @@ type RunInput struct {
 }
 
 type Run struct {
+	ID         string        `json:"id"`
 	Attributes RunAttributes `json:"attributes"`
 }
 
@@ -79,13 +373,145 @@ type VCSRepo struct {
 	IngressSubmodules bool   `json:"ingress-submodules"`
 	Identifier        string `json:"identifier"`
 	OauthTokenID      string `json:"oauth-token-id"`
+	GHAInstallationID string `json:"github-app-installation-id,omitempty"`
+}
+
+// VCS service providers of OAuth clients
+const (
+	ServiceProviderGitHub                  = "github"
+	ServiceProviderGitHubEnterprise        = "github_enterprise"
+	ServiceProviderGitLab                  = "gitlab_hosted"
+	ServiceProviderGitLabCommunityEdition  = "gitlab_community_edition"
+	ServiceProviderGitLabEnterpriseEdition = "gitlab_enterprise_edition"
+	ServiceProviderBitbucket               = "bitbucket_hosted"
+	ServiceProviderBitbucketServer         = "bitbucket_server"
+	ServiceProviderAzureDevOpsServices     = "ado_services"
+	ServiceProviderAzureDevOpsServer       = "ado_server"
+)
+
+// OAuthClient is a connection between an organization and a VCS provider
+type OAuthClient struct {
+	ID            string                `json:"id"`
+	Type          string                `json:"type"`
+	Attributes    OAuthClientAttributes `json:"attributes"`
+	Relationships Relationships         `json:"relationships"`
+}
+
+type OAuthClientAttributes struct {
+	Name                       string    `json:"name"`
+	ServiceProvider            string    `json:"service-provider"`
+	ServiceProviderDisplayName string    `json:"service-provider-display-name"`
+	HTTPURL                    string    `json:"http-url"`
+	APIURL                     string    `json:"api-url"`
+	Key                        string    `json:"key"`
+	CallbackURL                string    `json:"callback-url"`
+	ConnectPath                string    `json:"connect-path"`
+	CreatedAt                  time.Time `json:"created-at"`
+}
+
+// OAuthToken is the authorization of an OAuth client with its VCS provider,
+// whose ID is used to connect workspaces to repositories
+type OAuthToken struct {
+	ID            string               `json:"id"`
+	Type          string               `json:"type"`
+	Attributes    OAuthTokenAttributes `json:"attributes"`
+	Relationships Relationships        `json:"relationships"`
+}
+
+type OAuthTokenAttributes struct {
+	UID                 string    `json:"uid"`
+	HasSSHKey           bool      `json:"has-ssh-key"`
+	ServiceProviderUser string    `json:"service-provider-user"`
+	CreatedAt           time.Time `json:"created-at"`
+}
+
+// GHAInstallation is an installation of the Terraform Cloud GitHub App, an
+// alternative to OAuth tokens to connect workspaces to GitHub repositories
+type GHAInstallation struct {
+	ID         string                    `json:"id"`
+	Type       string                    `json:"type"`
+	Attributes GHAInstallationAttributes `json:"attributes"`
+}
+
+type GHAInstallationAttributes struct {
+	InstallationID   int64  `json:"installation-id"`
+	InstallationType string `json:"installation-type"`
+	InstallationURL  string `json:"installation-url"`
+	Name             string `json:"name"`
 }
 
 type Relationships map[string]Relationship
 
+// IDs returns the IDs of the objects related through the named
+// relationship, for both to-one and to-many relationships
+func (r Relationships) IDs(name string) []string {
+	rel, ok := r[name]
+	if !ok {
+		return nil
+	}
+	if rel.DataList == nil {
+		if rel.Data.ID == "" {
+			return nil
+		}
+		return []string{rel.Data.ID}
+	}
+	ids := make([]string, 0, len(rel.DataList))
+	for _, d := range rel.DataList {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+// Relationship links an object to one (Data) or many (DataList) other
+// objects
 type Relationship struct {
-	Data  RelationshipData `json:"data"`
-	Links Links            `json:"links"`
+	Data     RelationshipData   `json:"data"`
+	DataList []RelationshipData `json:"-"`
+	Links    Links              `json:"links"`
+}
+
+// UnmarshalJSON decodes to-one relationships into Data and to-many
+// relationships into DataList
+func (r *Relationship) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Data  json.RawMessage `json:"data"`
+		Links Links           `json:"links"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*r = Relationship{Links: raw.Links}
+	data := bytes.TrimSpace(raw.Data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil
+	case data[0] == '[':
+		r.DataList = []RelationshipData{}
+		return json.Unmarshal(data, &r.DataList)
+	}
+	return json.Unmarshal(data, &r.Data)
+}
+
+// MarshalJSON encodes DataList when it is set, and Data otherwise
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	var data interface{} = r.Data
+	if r.DataList != nil {
+		data = r.DataList
+	}
+	return json.Marshal(struct {
+		Data  interface{} `json:"data"`
+		Links Links       `json:"links,omitempty"`
+	}{data, r.Links})
+}
+
+// toMany builds a to-many relationship to objects of the given type
+func toMany(typ string, ids []string) Relationship {
+	data := make([]RelationshipData, 0, len(ids))
+	for _, id := range ids {
+		data = append(data, RelationshipData{Type: typ, ID: id})
+	}
+	return Relationship{DataList: data}
 }
 
 type RelationshipData struct {
@@ -105,10 +531,168 @@ type StateVersion struct {
 	Relationships Relationships          `json:"relationships"`
 }
 
+// RunID returns the ID of the run which created the state version, if any
+func (sv StateVersion) RunID() string {
+	if ids := sv.Relationships.IDs("run"); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
 type StateVersionAttributes struct {
 	CreatedAt              time.Time `json:"created-at"`
-	HostedStateDownloadURL string    `json:"hosted-state-download-url"`
+	HostedStateDownloadURL Secret    `json:"hosted-state-download-url"`
 	Serial                 int       `json:"serial"`
+	Status                 string    `json:"status"`
+	Size                   int64     `json:"size"`
+	ResourcesProcessed     bool      `json:"resources-processed"`
+	TerraformVersion       string    `json:"terraform-version"`
+	VCSCommitSHA           string    `json:"vcs-commit-sha"`
+	VCSCommitURL           string    `json:"vcs-commit-url"`
+	MD5                    string    `json:"md5"`
+}
+
+// ListStateVersionsOptions filters the state versions listed by
+// ListStateVersionsWithOptions. Zero values mean no filtering.
+type ListStateVersionsOptions struct {
+	// PageSize is the number of state versions per request, up to 100
+	PageSize int
+
+	// Since and Until bound the creation time of state versions, inclusively
+	Since time.Time
+	Until time.Time
+
+	// MaxCount is the maximum number of state versions returned, the most
+	// recent ones being kept
+	MaxCount int
+
+	// OldestFirst sorts the result from the oldest to the newest state
+	// version, instead of newest first
+	OldestFirst bool
+
+	// IncludeBase also returns the last state version created before Since,
+	// if any, which the first selected state version replaced. It is not
+	// counted in MaxCount.
+	IncludeBase bool
+}
+
+func (o ListStateVersionsOptions) sorted(svs []StateVersion) []StateVersion {
+	if o.OldestFirst {
+		for i, j := 0, len(svs)-1; i < j; i, j = i+1, j-1 {
+			svs[i], svs[j] = svs[j], svs[i]
+		}
+	}
+	return svs
+}
+
+// StateVersionOutput is an output of a state version. Value is null for
+// sensitive outputs, unless fetched with GetStateVersionOutput.
+type StateVersionOutput struct {
+	ID         string                       `json:"id"`
+	Type       string                       `json:"type"`
+	Attributes StateVersionOutputAttributes `json:"attributes"`
+	Links      Links                        `json:"links"`
+}
+
+type StateVersionOutputAttributes struct {
+	Name         string          `json:"name"`
+	Sensitive    bool            `json:"sensitive"`
+	Type         string          `json:"type"`
+	Value        json.RawMessage `json:"value"`
+	DetailedType json.RawMessage `json:"detailed-type"`
+}
+
+// WorkspaceResource is an instance of a resource managed by a workspace, as
+// listed by the workspace resources endpoint. Module is "root" for resources
+// of the root module, and ProviderType is the resource type.
+type WorkspaceResource struct {
+	ID         string                      `json:"id"`
+	Type       string                      `json:"type"`
+	Attributes WorkspaceResourceAttributes `json:"attributes"`
+}
+
+type WorkspaceResourceAttributes struct {
+	Address                  string    `json:"address"`
+	Name                     string    `json:"name"`
+	NameIndex                string    `json:"name-index"`
+	CreatedAt                time.Time `json:"created-at"`
+	UpdatedAt                time.Time `json:"updated-at"`
+	Module                   string    `json:"module"`
+	Provider                 string    `json:"provider"`
+	ProviderType             string    `json:"provider-type"`
+	ModifiedByStateVersionID string    `json:"modified-by-state-version-id"`
+}
+
+type CreateOrganizationOptions struct {
+	Name                   string `validate:"required"`
+	Email                  string `validate:"required"`
+	SessionTimeout         int
+	SessionRemember        int
+	CollaboratorAuthPolicy string
+	CostEstimationEnabled  bool
+	DefaultExecutionMode   string
+}
+
+// UpdateOrganizationOptions holds the organization settings to change, nil
+// fields are left untouched. Setting Name renames the organization.
+type UpdateOrganizationOptions struct {
+	Name                   *string
+	Email                  *string
+	SessionTimeout         *int
+	SessionRemember        *int
+	CollaboratorAuthPolicy *string
+	CostEstimationEnabled  *bool
+	DefaultExecutionMode   *string
+}
+
+// ListOrganizationMembershipsOptions filters organization memberships
+type ListOrganizationMembershipsOptions struct {
+	// Query searches memberships by username or email
+	Query string
+
+	// Emails restricts the memberships to these email addresses
+	Emails []string
+
+	// Status is either MembershipActive or MembershipInvited
+	Status string
+}
+
+// CreateTokenOptions configures a new API token. A zero ExpiredAt creates a
+// token which never expires.
+type CreateTokenOptions struct {
+	Description string
+	ExpiredAt   time.Time
+}
+
+type CreateTeamOptions struct {
+	Name               string `validate:"required"`
+	Visibility         string // secret or organization
+	SSOTeamID          string
+	OrganizationAccess *TeamOrganizationAccess
+}
+
+// UpdateTeamOptions holds the team settings to change, nil fields are left
+// untouched
+type UpdateTeamOptions struct {
+	Name               *string
+	Visibility         *string
+	SSOTeamID          *string
+	OrganizationAccess *TeamOrganizationAccess
+}
+
+// TeamAccessOptions sets the access of a team to a workspace. Permissions
+// only apply to AccessCustom.
+type TeamAccessOptions struct {
+	Access      string `validate:"required"`
+	Permissions WorkspacePermissions
+}
+
+// TeamProjectAccessOptions sets the access of a team to a project.
+// ProjectAccess and WorkspaceAccess only apply to AccessCustom.
+type TeamProjectAccessOptions struct {
+	Access          string `validate:"required"`
+	ProjectAccess   *ProjectPermissions
+	WorkspaceAccess *ProjectWorkspacePermissions
 }
 
 type CreateWorkspaceOptions struct {
@@ -116,21 +700,235 @@ type CreateWorkspaceOptions struct {
 	TerraformVersion string
 	VCSIdentifier    string
 	VCSOauthKeyID    string
+
+	// VCSGHAInstallationID connects the repository through a GitHub App
+	// installation instead of an OAuth token
+	VCSGHAInstallationID string
 }
 
+// CreateOAuthClientOptions configures a connection to a VCS provider.
+// HTTPURL and APIURL default to the public endpoints of hosted providers.
+// GitHub and GitLab connect with a personal access token in OAuthToken,
+// Bitbucket with the Key and Secret of an OAuth consumer, and Azure DevOps
+// Services with OAuthToken. PrivateKey and RSAPublicKey are for Bitbucket
+// Server and Azure DevOps Server.
+type CreateOAuthClientOptions struct {
+	Name            string
+	ServiceProvider string `validate:"required"`
+	HTTPURL         string
+	APIURL          string
+	OAuthToken      Secret
+	Key             string
+	Secret          Secret
+	PrivateKey      Secret
+	RSAPublicKey    string
+}
+
+// Variable categories
+const (
+	CategoryTerraform = "terraform"
+	CategoryEnv       = "env"
+)
+
 type CreateVariableOptions struct {
 	Key       string `validate:"required"`
 	Value     string `validate:"required"`
 	Category  string `validate:"required"`
 	Sensitive bool
 	HCL       bool
+
+	// ResolveSecrets resolves Value with Client.Secrets when it is a secret
+	// reference such as env://NAME, marking the variable as sensitive. Values
+	// are sent as is otherwise, so leave it unset for untrusted input.
+	ResolveSecrets bool
 }
 
-type AssignSSHKeyPayload struct {
-	Type string           `json:"type"`
-	Data SSHKeyAttributes `json:"data"`
+type CreateVariableSetOptions struct {
+	Name         string `validate:"required"`
+	Description  string
+	Global       bool
+	Priority     bool
+	WorkspaceIDs []string
+	ProjectIDs   []string
+}
+
+// UpdateVariableSetOptions holds the variable set settings to change, nil
+// fields are left untouched
+type UpdateVariableSetOptions struct {
+	Name        *string
+	Description *string
+	Global      *bool
+	Priority    *bool
+}
+
+// UpdateVariableOptions holds the variable settings to change, nil fields
+// are left untouched
+type UpdateVariableOptions struct {
+	Key         *string
+	Value       *string
+	Description *string
+	Category    *string
+	HCL         *bool
+	Sensitive   *bool
+
+	// ResolveSecrets resolves Value like CreateVariableOptions.ResolveSecrets
+	ResolveSecrets bool
+}
+
+// Policy kinds
+const (
+	PolicyKindSentinel = "sentinel"
+	PolicyKindOPA      = "opa"
+)
+
+// Policy enforcement levels. Sentinel policies are advisory, soft-mandatory
+// or hard-mandatory, and OPA policies advisory or mandatory.
+const (
+	EnforcementAdvisory      = "advisory"
+	EnforcementSoftMandatory = "soft-mandatory"
+	EnforcementHardMandatory = "hard-mandatory"
+	EnforcementMandatory     = "mandatory"
+)
+
+// Policy is a Sentinel or OPA policy, enforced on the workspaces of the
+// policy sets it belongs to
+type Policy struct {
+	ID            string           `json:"id"`
+	Type          string           `json:"type"`
+	Attributes    PolicyAttributes `json:"attributes"`
+	Relationships Relationships    `json:"relationships"`
+}
+
+type PolicyAttributes struct {
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Kind             string     `json:"kind"`
+	Query            string     `json:"query"`
+	EnforcementLevel string     `json:"enforcement-level"`
+	PolicySetCount   int        `json:"policy-set-count"`
+	UpdatedAt        *time.Time `json:"updated-at"`
 }
 
+// PolicySet groups policies, either managed through the API or read from
+// a VCS repository or uploaded versions, and applies them to workspaces
+// and projects, or globally
+type PolicySet struct {
+	ID            string              `json:"id"`
+	Type          string              `json:"type"`
+	Attributes    PolicySetAttributes `json:"attributes"`
+	Relationships Relationships       `json:"relationships"`
+}
+
+type PolicySetAttributes struct {
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	Kind              string    `json:"kind"`
+	Global            bool      `json:"global"`
+	Overridable       bool      `json:"overridable"`
+	PoliciesPath      string    `json:"policies-path"`
+	PolicyToolVersion string    `json:"policy-tool-version"`
+	VCSRepo           *VCSRepo  `json:"vcs-repo"`
+	PolicyCount       int       `json:"policy-count"`
+	WorkspaceCount    int       `json:"workspace-count"`
+	ProjectCount      int       `json:"project-count"`
+	CreatedAt         time.Time `json:"created-at"`
+	UpdatedAt         time.Time `json:"updated-at"`
+}
+
+// PolicySetVersion is an uploaded version of the policies of a policy set
+type PolicySetVersion struct {
+	ID         string                     `json:"id"`
+	Type       string                     `json:"type"`
+	Attributes PolicySetVersionAttributes `json:"attributes"`
+	Links      Links                      `json:"links"`
+}
+
+type PolicySetVersionAttributes struct {
+	Source           string               `json:"source"`
+	Status           string               `json:"status"`
+	StatusTimestamps map[string]time.Time `json:"status-timestamps"`
+	Error            string               `json:"error"`
+	CreatedAt        time.Time            `json:"created-at"`
+	UpdatedAt        time.Time            `json:"updated-at"`
+}
+
+// SSHKey is a private SSH key used to fetch modules from private git
+// repositories. Its value is never returned by the API.
+type SSHKey struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Attributes SSHKeyAttributes `json:"attributes"`
+}
+
+// SSHKeyAttributes are the attributes of an SSH key
 type SSHKeyAttributes struct {
-	ID string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreatePolicyOptions struct {
+	Name             string `validate:"required"`
+	Description      string
+	Kind             string // defaults to PolicyKindSentinel
+	Query            string // required for OPA policies, e.g. data.terraform.deny
+	EnforcementLevel string `validate:"required"`
+	PolicySetIDs     []string
+}
+
+// UpdatePolicyOptions holds the policy settings to change, nil fields are
+// left untouched
+type UpdatePolicyOptions struct {
+	Description      *string
+	Query            *string
+	EnforcementLevel *string
+}
+
+// CreatePolicySetOptions configures a new policy set. A policy set either
+// holds PolicyIDs, reads its policies from VCSRepo, or from versions
+// uploaded with UploadPolicySetVersion.
+type CreatePolicySetOptions struct {
+	Name              string `validate:"required"`
+	Description       string
+	Kind              string // defaults to PolicyKindSentinel
+	Global            bool
+	Overridable       bool
+	PoliciesPath      string
+	PolicyToolVersion string
+	VCSRepo           *VCSRepo
+	PolicyIDs         []string
+	WorkspaceIDs      []string
+	ProjectIDs        []string
+}
+
+// UpdatePolicySetOptions holds the policy set settings to change, nil fields
+// are left untouched
+type UpdatePolicySetOptions struct {
+	Name              *string
+	Description       *string
+	Global            *bool
+	Overridable       *bool
+	PoliciesPath      *string
+	PolicyToolVersion *string
+	VCSRepo           *VCSRepo
+}
+
+// UpdateSSHKeyOptions holds the SSH key settings to change, nil fields are
+// left untouched
+type UpdateSSHKeyOptions struct {
+	Name  *string
+	Value *Secret
+}
+
+// AssignSSHKeyPayload is the body of the SSH key relationship of a
+// workspace, a nil ID unassigning the key
+type AssignSSHKeyPayload struct {
+	Data AssignSSHKeyData `json:"data"`
+}
+
+type AssignSSHKeyData struct {
+	Type       string                 `json:"type"`
+	Attributes AssignSSHKeyAttributes `json:"attributes"`
+}
+
+type AssignSSHKeyAttributes struct {
+	ID *string `json:"id"`
 }
@@ -0,0 +1,41 @@
+package tfe
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRelationshipJSON(t *testing.T) {
+	var rels Relationships
+	err := json.Unmarshal([]byte(`{
+		"workspace": {"data": {"id": "ws-1", "type": "workspaces"}},
+		"vars": {"data": [{"id": "var-1", "type": "vars"}, {"id": "var-2", "type": "vars"}]},
+		"projects": {"data": []},
+		"run": {"data": null, "links": {"related": "/api/v2/runs/run-1"}}
+	}`), &rels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rels.IDs("workspace"); !reflect.DeepEqual(got, []string{"ws-1"}) {
+		t.Errorf("workspace: got %v", got)
+	}
+	if got := rels.IDs("vars"); !reflect.DeepEqual(got, []string{"var-1", "var-2"}) {
+		t.Errorf("vars: got %v", got)
+	}
+	if got := rels.IDs("projects"); len(got) != 0 || rels["projects"].DataList == nil {
+		t.Errorf("projects: got %v", got)
+	}
+	if got := rels.IDs("run"); got != nil {
+		t.Errorf("run: got %v", got)
+	}
+
+	b, err := json.Marshal(Relationships{"workspaces": toMany("workspaces", []string{"ws-1"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"workspaces":{"data":[{"id":"ws-1","type":"workspaces"}]}}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,332 @@
+package tfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrVariableSetNotFound is returned when a variable set does not exist or
+// is not visible to the token
+var ErrVariableSetNotFound = errors.New("Variable set not found")
+
+// variableSetPayload is the writable subset of a variable set
+type variableSetPayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name        *string `json:"name,omitempty"`
+		Description *string `json:"description,omitempty"`
+		Global      *bool   `json:"global,omitempty"`
+		Priority    *bool   `json:"priority,omitempty"`
+	} `json:"attributes"`
+	Relationships Relationships `json:"relationships,omitempty"`
+}
+
+// variablePayload is the writable subset of a variable
+type variablePayload struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Key         *string `json:"key,omitempty"`
+		Value       *string `json:"value,omitempty"`
+		Description *string `json:"description,omitempty"`
+		Category    *string `json:"category,omitempty"`
+		HCL         *bool   `json:"hcl,omitempty"`
+		Sensitive   *bool   `json:"sensitive,omitempty"`
+	} `json:"attributes"`
+}
+
+// ListVariableSets lists all variable sets of an organization
+// Requires P requests, where P is the number of pages
+// - /api/v2/organizations/:organizationName/varsets
+func (c *Client) ListVariableSets(organization string) ([]VariableSet, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/varsets", organization)
+	return listPages[VariableSet](c, path, nil)
+}
+
+// ListWorkspaceVariableSets lists the variable sets applied to a workspace,
+// whether directly, through its project, or globally
+// Requires P requests, where P is the number of pages
+// - /api/v2/workspaces/:workspaceID/varsets
+func (c *Client) ListWorkspaceVariableSets(workspaceID string) ([]VariableSet, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/varsets", workspaceID)
+	sets, err := listPages[VariableSet](c, path, nil)
+	if err == ErrNotFound {
+		return sets, ErrWorkspaceNotFound
+	}
+	return sets, err
+}
+
+// GetVariableSet gets a specific variable set
+// Requires 1 request:
+// - /api/v2/varsets/:variableSetID
+func (c *Client) GetVariableSet(variableSetID string) (VariableSet, error) {
+	path := fmt.Sprintf("/api/v2/varsets/%s", variableSetID)
+
+	type wrapper struct {
+		Data VariableSet `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do("GET", path, nil, nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return VariableSet{}, ErrVariableSetNotFound
+		}
+		return VariableSet{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateVariableSet creates a new variable set, optionally applied to
+// workspaces and projects
+// Requires 1 request:
+// - POST /api/v2/organizations/:organizationName/varsets
+func (c *Client) CreateVariableSet(organization string, options CreateVariableSetOptions) (VariableSet, error) {
+	path := fmt.Sprintf("/api/v2/organizations/%s/varsets", organization)
+
+	payload := variableSetPayload{Type: "varsets"}
+	payload.Attributes.Name = &options.Name
+	payload.Attributes.Description = &options.Description
+	payload.Attributes.Global = &options.Global
+	payload.Attributes.Priority = &options.Priority
+	payload.Relationships = Relationships{}
+	if len(options.WorkspaceIDs) > 0 {
+		payload.Relationships["workspaces"] = toMany("workspaces", options.WorkspaceIDs)
+	}
+	if len(options.ProjectIDs) > 0 {
+		payload.Relationships["projects"] = toMany("projects", options.ProjectIDs)
+	}
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return VariableSet{}, err
+	}
+
+	type wrapperResp struct {
+		Data VariableSet `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		return VariableSet{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// UpdateVariableSet updates the settings of a variable set
+// Requires 1 request:
+// - PATCH /api/v2/varsets/:variableSetID
+func (c *Client) UpdateVariableSet(variableSetID string, options UpdateVariableSetOptions) (VariableSet, error) {
+	path := fmt.Sprintf("/api/v2/varsets/%s", variableSetID)
+
+	payload := variableSetPayload{Type: "varsets"}
+	payload.Attributes.Name = options.Name
+	payload.Attributes.Description = options.Description
+	payload.Attributes.Global = options.Global
+	payload.Attributes.Priority = options.Priority
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return VariableSet{}, err
+	}
+
+	type wrapperResp struct {
+		Data VariableSet `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPatch, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return VariableSet{}, ErrVariableSetNotFound
+		}
+		return VariableSet{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteVariableSet deletes a variable set and its variables
+// Requires 1 request:
+// - DELETE /api/v2/varsets/:variableSetID
+func (c *Client) DeleteVariableSet(variableSetID string) error {
+	path := fmt.Sprintf("/api/v2/varsets/%s", variableSetID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrVariableSetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListVariableSetVariables lists the variables of a variable set
+// Requires P requests, where P is the number of pages
+// - /api/v2/varsets/:variableSetID/relationships/vars
+func (c *Client) ListVariableSetVariables(variableSetID string) ([]Variable, error) {
+	path := fmt.Sprintf("/api/v2/varsets/%s/relationships/vars", variableSetID)
+	vars, err := listPages[Variable](c, path, nil)
+	if err == ErrNotFound {
+		return vars, ErrVariableSetNotFound
+	}
+	return vars, err
+}
+
+// CreateVariableSetVariable adds a new variable to a variable set
+// Requires 1 request:
+// - POST /api/v2/varsets/:variableSetID/relationships/vars
+func (c *Client) CreateVariableSetVariable(variableSetID string, options CreateVariableOptions) (Variable, error) {
+	path := fmt.Sprintf("/api/v2/varsets/%s/relationships/vars", variableSetID)
+
+	options, err := c.resolveVariable(options)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	payload := variablePayload{Type: "vars"}
+	payload.Attributes.Key = &options.Key
+	payload.Attributes.Value = &options.Value
+	payload.Attributes.Category = &options.Category
+	payload.Attributes.HCL = &options.HCL
+	payload.Attributes.Sensitive = &options.Sensitive
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return Variable{}, err
+	}
+
+	type wrapperResp struct {
+		Data Variable `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPost, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Variable{}, ErrVariableSetNotFound
+		}
+		return Variable{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// UpdateVariableSetVariable updates a variable of a variable set
+// Requires 1 request:
+// - PATCH /api/v2/varsets/:variableSetID/relationships/vars/:variableID
+func (c *Client) UpdateVariableSetVariable(variableSetID, variableID string, options UpdateVariableOptions) (Variable, error) {
+	path := fmt.Sprintf("/api/v2/varsets/%s/relationships/vars/%s", variableSetID, variableID)
+
+	if options.Value != nil {
+		resolved, err := c.resolveVariable(CreateVariableOptions{Value: *options.Value, ResolveSecrets: options.ResolveSecrets})
+		if err != nil {
+			return Variable{}, err
+		}
+		if resolved.Sensitive {
+			options.Value = &resolved.Value
+			options.Sensitive = &resolved.Sensitive
+		}
+	}
+
+	payload := variablePayload{Type: "vars"}
+	payload.Attributes.Key = options.Key
+	payload.Attributes.Value = options.Value
+	payload.Attributes.Description = options.Description
+	payload.Attributes.Category = options.Category
+	payload.Attributes.HCL = options.HCL
+	payload.Attributes.Sensitive = options.Sensitive
+
+	type wrapper struct {
+		Data interface{} `json:"data"`
+	}
+
+	b, err := json.Marshal(wrapper{Data: payload})
+	if err != nil {
+		return Variable{}, err
+	}
+
+	type wrapperResp struct {
+		Data Variable `json:"data"`
+	}
+	var resp wrapperResp
+	if err := c.do(http.MethodPatch, path, bytes.NewBuffer(b), nil, &resp); err != nil {
+		if err == ErrNotFound {
+			return Variable{}, ErrVariableSetNotFound
+		}
+		return Variable{}, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteVariableSetVariable deletes a variable from a variable set
+// Requires 1 request:
+// - DELETE /api/v2/varsets/:variableSetID/relationships/vars/:variableID
+func (c *Client) DeleteVariableSetVariable(variableSetID, variableID string) error {
+	path := fmt.Sprintf("/api/v2/varsets/%s/relationships/vars/%s", variableSetID, variableID)
+
+	if err := c.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrVariableSetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ApplyVariableSetToWorkspaces applies a variable set to workspaces
+// Requires 1 request:
+// - POST /api/v2/varsets/:variableSetID/relationships/workspaces
+func (c *Client) ApplyVariableSetToWorkspaces(variableSetID string, workspaceIDs ...string) error {
+	return c.updateVariableSetRelationship(http.MethodPost, variableSetID, "workspaces", workspaceIDs)
+}
+
+// RemoveVariableSetFromWorkspaces removes a variable set from workspaces
+// Requires 1 request:
+// - DELETE /api/v2/varsets/:variableSetID/relationships/workspaces
+func (c *Client) RemoveVariableSetFromWorkspaces(variableSetID string, workspaceIDs ...string) error {
+	return c.updateVariableSetRelationship(http.MethodDelete, variableSetID, "workspaces", workspaceIDs)
+}
+
+// ApplyVariableSetToProjects applies a variable set to projects, and so to
+// all of their workspaces
+// Requires 1 request:
+// - POST /api/v2/varsets/:variableSetID/relationships/projects
+func (c *Client) ApplyVariableSetToProjects(variableSetID string, projectIDs ...string) error {
+	return c.updateVariableSetRelationship(http.MethodPost, variableSetID, "projects", projectIDs)
+}
+
+// RemoveVariableSetFromProjects removes a variable set from projects
+// Requires 1 request:
+// - DELETE /api/v2/varsets/:variableSetID/relationships/projects
+func (c *Client) RemoveVariableSetFromProjects(variableSetID string, projectIDs ...string) error {
+	return c.updateVariableSetRelationship(http.MethodDelete, variableSetID, "projects", projectIDs)
+}
+
+func (c *Client) updateVariableSetRelationship(method, variableSetID, typ string, ids []string) error {
+	path := fmt.Sprintf("/api/v2/varsets/%s/relationships/%s", variableSetID, typ)
+
+	b, err := json.Marshal(toMany(typ, ids))
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(method, path, bytes.NewBuffer(b), nil, nil); err != nil {
+		if err == ErrNotFound {
+			return ErrVariableSetNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package tfe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// recordedRequest is a request received by a recordingServer
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// recordingServer records the requests it receives and answers them with
+// the given status and body
+func recordingServer(t *testing.T, status int, body string) (*httptest.Server, *[]recordedRequest) {
+	requests := []recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := recordedRequest{Method: r.Method, Path: r.URL.Path}
+		if b, _ := ioutil.ReadAll(r.Body); len(b) > 0 {
+			if err := json.Unmarshal(b, &req.Body); err != nil {
+				t.Errorf("invalid body %s", b)
+			}
+		}
+		requests = append(requests, req)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestCreateVariableSet(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusCreated, `{"data": {"id": "varset-1", "attributes": {"name": "aws"}}}`)
+
+	set, err := New("token", server.URL).CreateVariableSet("org", CreateVariableSetOptions{
+		Name:         "aws",
+		Priority:     true,
+		WorkspaceIDs: []string{"ws-1"},
+		ProjectIDs:   []string{"prj-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.ID != "varset-1" {
+		t.Errorf("got %+v", set)
+	}
+
+	want := []recordedRequest{{
+		Method: http.MethodPost,
+		Path:   "/api/v2/organizations/org/varsets",
+		Body: map[string]interface{}{"data": map[string]interface{}{
+			"type": "varsets",
+			"attributes": map[string]interface{}{
+				"name":        "aws",
+				"description": "",
+				"global":      false,
+				"priority":    true,
+			},
+			"relationships": map[string]interface{}{
+				"workspaces": map[string]interface{}{"data": []interface{}{map[string]interface{}{"id": "ws-1", "type": "workspaces"}}},
+				"projects":   map[string]interface{}{"data": []interface{}{map[string]interface{}{"id": "prj-1", "type": "projects"}}},
+			},
+		}},
+	}}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got %+v", *requests)
+	}
+}
+
+func TestUpdateVariableSet(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK, `{"data": {"id": "varset-1"}}`)
+
+	global := false
+	if _, err := New("token", server.URL).UpdateVariableSet("varset-1", UpdateVariableSetOptions{Global: &global}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []recordedRequest{{
+		Method: http.MethodPatch,
+		Path:   "/api/v2/varsets/varset-1",
+		Body: map[string]interface{}{"data": map[string]interface{}{
+			"type":       "varsets",
+			"attributes": map[string]interface{}{"global": false},
+		}},
+	}}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got %+v", *requests)
+	}
+}
+
+func TestVariableSetRelationships(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusNoContent, "")
+	c := New("token", server.URL)
+
+	calls := []func() error{
+		func() error { return c.ApplyVariableSetToWorkspaces("varset-1", "ws-1", "ws-2") },
+		func() error { return c.RemoveVariableSetFromWorkspaces("varset-1", "ws-1") },
+		func() error { return c.ApplyVariableSetToProjects("varset-1", "prj-1") },
+		func() error { return c.RemoveVariableSetFromProjects("varset-1", "prj-1") },
+	}
+	for _, call := range calls {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ref := func(typ string, ids ...string) map[string]interface{} {
+		data := []interface{}{}
+		for _, id := range ids {
+			data = append(data, map[string]interface{}{"id": id, "type": typ})
+		}
+		return map[string]interface{}{"data": data}
+	}
+	want := []recordedRequest{
+		{http.MethodPost, "/api/v2/varsets/varset-1/relationships/workspaces", ref("workspaces", "ws-1", "ws-2")},
+		{http.MethodDelete, "/api/v2/varsets/varset-1/relationships/workspaces", ref("workspaces", "ws-1")},
+		{http.MethodPost, "/api/v2/varsets/varset-1/relationships/projects", ref("projects", "prj-1")},
+		{http.MethodDelete, "/api/v2/varsets/varset-1/relationships/projects", ref("projects", "prj-1")},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got %+v", *requests)
+	}
+}
+
+func TestVariableSetNotFound(t *testing.T) {
+	server, _ := recordingServer(t, http.StatusNotFound, "")
+	c := New("token", server.URL)
+
+	value := "x"
+	errs := map[string]error{
+		"GetVariableSet": func() error { _, err := c.GetVariableSet("varset-1"); return err }(),
+		"UpdateVariableSetVariable": func() error {
+			_, err := c.UpdateVariableSetVariable("varset-1", "var-1", UpdateVariableOptions{Value: &value})
+			return err
+		}(),
+		"DeleteVariableSetVariable":    c.DeleteVariableSetVariable("varset-1", "var-1"),
+		"ApplyVariableSetToWorkspaces": c.ApplyVariableSetToWorkspaces("varset-1", "ws-1"),
+	}
+	for name, err := range errs {
+		if err != ErrVariableSetNotFound {
+			t.Errorf("%s: got %v, want ErrVariableSetNotFound", name, err)
+		}
+	}
+}
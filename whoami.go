@@ -0,0 +1,187 @@
+package tfe
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrPermissionDenied is returned by CanI when an action is not permitted
+var ErrPermissionDenied = errors.New("Permission denied")
+
+// ErrUnknownPermission is returned by CanI when a resource does not report
+// the permission of an action, e.g. a misspelt action, or a resource listed
+// without its permissions
+var ErrUnknownPermission = errors.New("Unknown permission")
+
+// TokenKind is the kind of owner of an API token
+type TokenKind string
+
+// Token kinds
+const (
+	TokenUser         TokenKind = "user"
+	TokenTeam         TokenKind = "team"
+	TokenOrganization TokenKind = "organization"
+
+	// TokenUnknown is the kind of service account tokens whose account
+	// details relate them to neither a team nor an organization
+	TokenUnknown TokenKind = "unknown"
+)
+
+// Identity describes the owner of an API token
+type Identity struct {
+	Kind TokenKind
+
+	// User is the account of the token, a service account for team and
+	// organization tokens
+	User User
+
+	// Organization is the organization team and organization tokens are
+	// bound to, when known
+	Organization string
+
+	// Team is the team a team token is bound to. Only its ID is set when the
+	// token may not read the team.
+	Team *Team
+}
+
+// String describes the identity, e.g. team token of devs in acme
+func (id Identity) String() string {
+	switch {
+	case id.Kind == TokenUser:
+		return fmt.Sprintf("user token of %s", id.User.Attributes.Username)
+	case id.Team != nil:
+		name := id.Team.Attributes.Name
+		if name == "" {
+			name = id.Team.ID
+		}
+		if id.Organization == "" {
+			return fmt.Sprintf("team token of %s", name)
+		}
+		return fmt.Sprintf("team token of %s in %s", name, id.Organization)
+	case id.Organization != "":
+		return fmt.Sprintf("%s token of %s", id.Kind, id.Organization)
+	}
+	return fmt.Sprintf("%s token", id.Kind)
+}
+
+// WhoAmI reports whether the token of the client is a user, team or
+// organization token, and which organization and team it is bound to.
+// Team and organization tokens authenticate as service accounts, whose
+// account details relate them to their team or organization. The kind is
+// TokenUnknown for service accounts without either relationship, e.g. on
+// Terraform Enterprise versions which do not report them.
+// Requires 1 request, plus 1 for team tokens:
+// - /api/v2/account/details
+// - GetTeam (1)
+func (c *Client) WhoAmI() (Identity, error) {
+	user, err := c.GetCurrentUser()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	id := Identity{Kind: TokenUser, User: user}
+	if !user.Attributes.IsServiceAccount {
+		return id, nil
+	}
+
+	teams := user.Relationships.IDs("team")
+	orgs := user.Relationships.IDs("organization")
+	switch {
+	case len(teams) == 1:
+		id.Kind = TokenTeam
+		if len(orgs) == 1 {
+			id.Organization = orgs[0]
+		}
+		team, err := c.GetTeam(teams[0])
+		if err == ErrTeamNotFound {
+			// Team tokens may not be allowed to read their team
+			id.Team = &Team{ID: teams[0]}
+			return id, nil
+		}
+		if err != nil {
+			return id, err
+		}
+		id.Team = &team
+		if teamOrgs := team.Relationships.IDs("organization"); len(teamOrgs) == 1 {
+			id.Organization = teamOrgs[0]
+		}
+	case len(orgs) == 1:
+		id.Kind = TokenOrganization
+		id.Organization = orgs[0]
+	default:
+		id.Kind = TokenUnknown
+	}
+	return id, nil
+}
+
+// CanI checks whether the token which fetched a resource may perform an
+// action on it, using the permissions reported with the resource, so that
+// tools can fail early with a clear message. Actions are permission names,
+// with or without their can- prefix, e.g. create-workspace on an
+// Organization, queue-run on a Workspace or apply on a Run. Resources are
+// Organization, Workspace, Run, Team, Project or User values, or pointers to
+// them. It returns nil when the action is permitted, an error wrapping
+// ErrPermissionDenied when it is not, and one wrapping ErrUnknownPermission
+// when the resource does not report the permission.
+func CanI(action string, resource interface{}) error {
+	kind, name, permissions, err := resourcePermissions(resource)
+	if err != nil {
+		return err
+	}
+
+	permission := action
+	if !strings.HasPrefix(permission, "can-") {
+		permission = "can-" + permission
+	}
+
+	allowed, ok := permissions[permission]
+	if !ok {
+		known := make([]string, 0, len(permissions))
+		for p := range permissions {
+			known = append(known, p)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("%w: %s on %s %q, known permissions are %s", ErrUnknownPermission, permission, kind, name, strings.Join(known, ", "))
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s on %s %q", ErrPermissionDenied, permission, kind, name)
+	}
+	return nil
+}
+
+func resourcePermissions(resource interface{}) (kind, name string, permissions map[string]bool, err error) {
+	if v := reflect.ValueOf(resource); v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", "", nil, fmt.Errorf("nil %T has no permissions", resource)
+	}
+
+	switch r := resource.(type) {
+	case Organization:
+		return "organization", r.ID, r.Attributes.Permissions, nil
+	case *Organization:
+		return resourcePermissions(*r)
+	case Workspace:
+		return "workspace", r.Attributes.Name, r.Attributes.Permissions, nil
+	case *Workspace:
+		return resourcePermissions(*r)
+	case Run:
+		return "run", r.ID, r.Attributes.Permissions, nil
+	case *Run:
+		return resourcePermissions(*r)
+	case Team:
+		return "team", r.Attributes.Name, r.Attributes.Permissions, nil
+	case *Team:
+		return resourcePermissions(*r)
+	case Project:
+		return "project", r.Attributes.Name, r.Attributes.Permissions, nil
+	case *Project:
+		return resourcePermissions(*r)
+	case User:
+		return "user", r.Attributes.Username, r.Attributes.Permissions, nil
+	case *User:
+		return resourcePermissions(*r)
+	}
+	return "", "", nil, fmt.Errorf("resources of type %T have no permissions", resource)
+}
@@ -0,0 +1,82 @@
+package tfe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhoAmI(t *testing.T) {
+	tests := []struct {
+		account string
+		want    string
+		kind    TokenKind
+	}{
+		{`"attributes": {"username": "alice"}`, "user token of alice", TokenUser},
+		{
+			`"attributes": {"username": "api-org-acme-corp-1234", "is-service-account": true},
+			"relationships": {"organization": {"data": {"id": "acme-corp", "type": "organizations"}}}`,
+			"organization token of acme-corp", TokenOrganization,
+		},
+		{
+			`"attributes": {"username": "api-team_XyZ12", "is-service-account": true},
+			"relationships": {"team": {"data": {"id": "team-XyZ12", "type": "teams"}}}`,
+			"team token of devs in acme", TokenTeam,
+		},
+		{
+			`"attributes": {"username": "api-team_Hidden", "is-service-account": true},
+			"relationships": {"team": {"data": {"id": "team-Hidden", "type": "teams"}}, "organization": {"data": {"id": "acme", "type": "organizations"}}}`,
+			"team token of team-Hidden in acme", TokenTeam,
+		},
+		// Usernames are not trusted to tell service accounts apart
+		{`"attributes": {"username": "api-org-acme-1234", "is-service-account": true}`, "unknown token", TokenUnknown},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v2/account/details":
+				fmt.Fprintf(w, `{"data": {"id": "user-1", %s}}`, tt.account)
+			case "/api/v2/teams/team-XyZ12":
+				fmt.Fprint(w, `{"data": {"id": "team-XyZ12", "attributes": {"name": "devs"}, "relationships": {"organization": {"data": {"id": "acme", "type": "organizations"}}}}}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		id, err := New("token", server.URL).WhoAmI()
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.String() != tt.want || id.Kind != tt.kind {
+			t.Errorf("got %s %q, want %s %q", id.Kind, id, tt.kind, tt.want)
+		}
+	}
+}
+
+func TestCanI(t *testing.T) {
+	ws := Workspace{Attributes: WorkspaceAttributes{
+		Name:        "network",
+		Permissions: map[string]bool{"can-queue-run": true, "can-destroy": false},
+	}}
+
+	if err := CanI("queue-run", ws); err != nil {
+		t.Errorf("got %v", err)
+	}
+	if err := CanI("can-destroy", &ws); !errors.Is(err, ErrPermissionDenied) || err.Error() != `Permission denied: can-destroy on workspace "network"` {
+		t.Errorf("got %v", err)
+	}
+	if err := CanI("fly", ws); !errors.Is(err, ErrUnknownPermission) {
+		t.Errorf("got %v", err)
+	}
+	if err := CanI("read", "network"); err == nil {
+		t.Error("expected an error for a string resource")
+	}
+	for _, resource := range []interface{}{(*Run)(nil), (*Workspace)(nil), (*User)(nil), nil} {
+		if err := CanI("read", resource); err == nil {
+			t.Errorf("expected an error for %#v", resource)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package tfe
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ListWorkspaceResources lists the resource instances managed by a workspace,
+// without downloading its state
+// Requires P requests, where P is the number of pages
+// - /api/v2/workspaces/:workspaceID/resources
+func (c *Client) ListWorkspaceResources(workspaceID string) ([]WorkspaceResource, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/resources", workspaceID)
+
+	q := url.Values{}
+	q.Add("page[size]", "100")
+	return listPages[WorkspaceResource](c, path, q)
+}
+
+// CountWorkspaceResources returns the number of resource instances managed
+// by a workspace
+// Requires 1 request:
+// - /api/v2/workspaces/:workspaceID/resources
+func (c *Client) CountWorkspaceResources(workspaceID string) (int, error) {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/resources", workspaceID)
+
+	q := url.Values{}
+	q.Add("page[size]", "1")
+
+	var resp PaginatedResponse
+	if err := c.do("GET", path, nil, q, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Meta.Pagination.TotalCount, nil
+}
+
+// ListWorkspaceResourceAddresses lists the addresses of the resource
+// instances managed by a workspace, e.g. module.vpc.aws_subnet.private[0]
+// Requires P requests, where P is the number of pages
+// - ListWorkspaceResources (P)
+func (c *Client) ListWorkspaceResourceAddresses(workspaceID string) ([]string, error) {
+	resources, err := c.ListWorkspaceResources(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		addrs = append(addrs, r.InstanceAddress())
+	}
+	return addrs, nil
+}
+
+// ModuleAddress returns the address of the module of the resource, e.g.
+// module.vpc, or an empty string for the root module
+func (r WorkspaceResource) ModuleAddress() string {
+	if r.Attributes.Module == "" || r.Attributes.Module == "root" {
+		return ""
+	}
+	return moduleAddressV3(append([]string{"root"}, splitModulePath(r.Attributes.Module)...))
+}
+
+// ResourceName returns the name of the resource, without its index
+func (r WorkspaceResource) ResourceName() string {
+	name := r.Attributes.Name
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// InstanceAddress returns the full address of the resource instance, in the
+// same format as StateResource.InstanceAddress
+func (r WorkspaceResource) InstanceAddress() string {
+	sr := StateResource{
+		Module: r.ModuleAddress(),
+		Type:   r.Attributes.ProviderType,
+		Name:   r.ResourceName(),
+	}
+	addr := sr.Address()
+	if i := len(sr.Name); i < len(r.Attributes.Name) {
+		addr += r.Attributes.Name[i:]
+	}
+	return addr
+}
+
+// splitModulePath splits a module path such as parent.child or
+// module.parent.module.child into module names
+func splitModulePath(module string) []string {
+	parts := []string{}
+	for _, p := range strings.Split(module, ".") {
+		if p != "" && p != "module" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,406 @@
+package tfe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-blank line of a YAML document, without its comment
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// yamlParser parses the block subset of YAML used by configuration files
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML parses a YAML document into the generic values of encoding/json:
+// map[string]interface{}, []interface{}, string, bool, json.Number and nil.
+// It supports block mappings and sequences nested by indentation, plain,
+// single and double quoted scalars, single-line flow collections of
+// scalars such as [a, b] or {runs: apply}, comments and a leading ---
+// marker. Anchors, aliases, tags, multi-line scalars and multiple documents
+// are rejected.
+func parseYAML(src []byte) (interface{}, error) {
+	lines, err := yamlLines(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("yaml: empty document")
+	}
+
+	p := &yamlParser{lines: lines}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected %q", p.lines[p.pos].text)
+	}
+	return v, nil
+}
+
+// yamlLines splits a document into lines, dropping comments, blank lines
+// and the document start marker
+func yamlLines(src []byte) ([]yamlLine, error) {
+	lines := []yamlLine{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), len(src)+1)
+	num := 0
+	for scanner.Scan() {
+		num++
+		raw := strings.TrimRight(stripYAMLComment(scanner.Text()), " \t\r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("yaml:%d: tabs are not allowed in indentation", num)
+		}
+		if text == "---" && len(lines) == 0 {
+			continue
+		}
+		if text == "---" || text == "..." || text[0] == '%' {
+			return nil, fmt.Errorf("yaml:%d: multiple documents and directives are not supported", num)
+		}
+		lines = append(lines, yamlLine{num: num, indent: len(raw) - len(text), text: text})
+	}
+	return lines, scanner.Err()
+}
+
+// stripYAMLComment removes the comment of a line, a # at its start or
+// preceded by a space, outside of quoted scalars
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,", line[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return line
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	num := 0
+	if p.pos < len(p.lines) {
+		num = p.lines[p.pos].num
+	} else if len(p.lines) > 0 {
+		num = p.lines[len(p.lines)-1].num
+	}
+	return fmt.Errorf("yaml:%d: %s", num, fmt.Sprintf(format, args...))
+}
+
+// block parses the mapping or sequence starting at the current line, whose
+// entries are indented by indent
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) ([]interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || line.indent == indent && !isYAMLSequenceItem(line.text) {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			p.pos++
+			item, err := p.nested(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// The content of "- key: value" or "- - item" is a block indented
+		// by its column
+		if _, _, ok := splitYAMLEntry(rest); ok || isYAMLSequenceItem(rest) {
+			column := line.indent + len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{num: line.num, indent: column, text: rest}
+			item, err := p.block(column)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		item, err := p.scalar(rest)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.pos++
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if isYAMLSequenceItem(line.text) {
+			return nil, p.errorf("unexpected sequence item in a mapping")
+		}
+
+		key, value, ok := splitYAMLEntry(line.text)
+		if !ok {
+			return nil, p.errorf("expected key: value, got %q", line.text)
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+
+		if value != "" {
+			v, err := p.scalar(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		v, err := p.nested(indent, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// nested parses the block value of a key or sequence item without inline
+// value, null when the next line is not indented further. Sequences may be
+// indented like the key they belong to.
+func (p *yamlParser) nested(indent int, inMapping bool) (interface{}, error) {
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	switch {
+	case next.indent > indent:
+		return p.block(next.indent)
+	case inMapping && next.indent == indent && isYAMLSequenceItem(next.text):
+		return p.sequence(indent)
+	}
+	return nil, nil
+}
+
+// scalar parses an inline value: a scalar or a flow collection of scalars
+func (p *yamlParser) scalar(s string) (interface{}, error) {
+	switch s[0] {
+	case '[':
+		if s[len(s)-1] != ']' {
+			return nil, p.errorf("multi-line flow sequences are not supported")
+		}
+		items := []interface{}{}
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			v, err := p.flowScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case '{':
+		if s[len(s)-1] != '}' {
+			return nil, p.errorf("multi-line flow mappings are not supported")
+		}
+		m := map[string]interface{}{}
+		for _, entry := range splitYAMLFlow(s[1 : len(s)-1]) {
+			key, value, ok := splitYAMLEntry(entry)
+			if !ok {
+				return nil, p.errorf("expected key: value, got %q", entry)
+			}
+			v, err := p.flowScalar(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	case '&', '*', '!':
+		return nil, p.errorf("anchors, aliases and tags are not supported")
+	case '|', '>':
+		return nil, p.errorf("multi-line scalars are not supported")
+	case '"', '\'':
+		v, n, err := unquoteYAML(s)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if n != len(s) {
+			return nil, p.errorf("unexpected %q after quoted scalar", s[n:])
+		}
+		return v, nil
+	}
+
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
+
+// flowScalar parses an element of a flow collection, which cannot be a
+// collection itself
+func (p *yamlParser) flowScalar(s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] == '[' || s[0] == '{' {
+		return nil, p.errorf("nested flow collections are not supported")
+	}
+	return p.scalar(s)
+}
+
+// isYAMLSequenceItem reports whether a line starts a sequence item
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLEntry splits a "key: value" mapping entry, the key being plain or
+// quoted
+func splitYAMLEntry(text string) (key, value string, ok bool) {
+	if text == "" {
+		return "", "", false
+	}
+	rest := text
+	if text[0] == '"' || text[0] == '\'' {
+		k, n, err := unquoteYAML(text)
+		if err != nil {
+			return "", "", false
+		}
+		key, rest = k, text[n:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	if strings.IndexByte("?&*!|>[{", text[0]) >= 0 {
+		return "", "", false
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:i])
+	return key, strings.TrimSpace(text[i+1:]), key != ""
+}
+
+// splitYAMLFlow splits the content of a flow collection on the commas
+// outside of quoted scalars
+func splitYAMLFlow(s string) []string {
+	parts := []string{}
+	if strings.TrimSpace(s) == "" {
+		return parts
+	}
+
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	// A trailing comma is allowed, as in [a, b, ]
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// unquoteYAML parses the quoted scalar at the start of s, returning its
+// value and length. Double quoted scalars support the JSON escapes, and
+// single quoted ones a doubled single quote.
+func unquoteYAML(s string) (string, int, error) {
+	if s[0] == '\'' {
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		return "", 0, errors.New("unterminated single quoted scalar")
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			var v string
+			if err := json.Unmarshal([]byte(s[:i+1]), &v); err != nil {
+				return "", 0, fmt.Errorf("invalid double quoted scalar %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, errors.New("unterminated double quoted scalar")
+}
@@ -0,0 +1,87 @@
+package tfe
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	src := `---
+name: "quoted # not a comment"
+plain: bob's value # comment
+single: 'it''s'
+url: https://example.com:8443/path
+count: 3
+enabled: true
+missing: ~
+empty:
+list:
+  - a
+  -
+  - - nested
+    - list
+  - key: value
+    other: 2
+flow: [x, 'y, z', 1]
+map: {a: b}
+trailing: {a: 1, }
+trailingList: [a, b, ]
+indentless:
+- one
+- two
+`
+	got, err := parseYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"name":    "quoted # not a comment",
+		"plain":   "bob's value",
+		"single":  "it's",
+		"url":     "https://example.com:8443/path",
+		"count":   json.Number("3"),
+		"enabled": true,
+		"missing": nil,
+		"empty":   nil,
+		"list": []interface{}{
+			"a",
+			nil,
+			[]interface{}{"nested", "list"},
+			map[string]interface{}{"key": "value", "other": json.Number("2")},
+		},
+		"flow":         []interface{}{"x", "y, z", json.Number("1")},
+		"map":          map[string]interface{}{"a": "b"},
+		"trailing":     map[string]interface{}{"a": json.Number("1")},
+		"trailingList": []interface{}{"a", "b"},
+		"indentless":   []interface{}{"one", "two"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"# only a comment\n",
+		"a: 1\na: 2\n",
+		"a: 1\n  b: 2\n",
+		"a:\n\t- b\n",
+		"a: *alias\n",
+		"a: !!str b\n",
+		"a: |\n  text\n",
+		"a: [b, [c]]\n",
+		"a: {b: 1, , c: 2}\n",
+		"a: [b,\n  c]\n",
+		"a: 'unterminated\n",
+		"a: 1\n---\nb: 2\n",
+		"- a\nb: c\n",
+		"a\n",
+	} {
+		if v, err := parseYAML([]byte(src)); err == nil {
+			t.Errorf("%q: expected an error, got %#v", src, v)
+		}
+	}
+}